@@ -0,0 +1,65 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Group ID",type=string,JSONPath=`.status.groupId`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AccessGroup manages a Cloudflare Access group, a named set of users such
+// as "on-call engineers". Access rules match its members with groupRef.
+type AccessGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AccessGroupSpec   `json:"spec,omitempty"`
+	Status AccessGroupStatus `json:"status,omitempty"`
+}
+
+type AccessGroupSpec struct {
+	// Name of the group in Cloudflare. Defaults to metadata.name.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Include lists the rules of which a member must match at least one.
+	// Role and claim rules are checked against the Zitadel identity provider.
+	// +kubebuilder:validation:MinItems=1
+	Include []AccessRule `json:"include"`
+
+	// Require lists rules every member must match in addition.
+	// +optional
+	Require []AccessRule `json:"require,omitempty"`
+
+	// Exclude lists rules that stop a user from being a member.
+	// +optional
+	Exclude []AccessRule `json:"exclude,omitempty"`
+}
+
+type AccessGroupStatus struct {
+	// GroupID is the Cloudflare Access group ID.
+	GroupID string `json:"groupId,omitempty"`
+
+	// ObservedGeneration is the spec generation that was last fully reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AccessGroupList contains a list of AccessGroup.
+type AccessGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AccessGroup{}, &AccessGroupList{})
+}
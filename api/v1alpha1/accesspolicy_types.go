@@ -0,0 +1,76 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Decision",type=string,JSONPath=`.spec.decision`
+// +kubebuilder:printcolumn:name="Policy ID",type=string,JSONPath=`.status.policyId`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AccessPolicy manages a reusable account-level Cloudflare Access policy.
+// SecuredApplications attach it through spec.access.policyRefs, so a rule
+// shared by many applications is edited in one place.
+type AccessPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AccessPolicySpec   `json:"spec,omitempty"`
+	Status AccessPolicyStatus `json:"status,omitempty"`
+}
+
+type AccessPolicySpec struct {
+	// Name of the policy in Cloudflare. Defaults to metadata.name.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Decision is the action taken for matching requests.
+	// +kubebuilder:validation:Enum=allow;deny;bypass;non_identity
+	Decision string `json:"decision"`
+
+	// Include lists the rules of which a request must match at least one.
+	// Role and claim rules are checked against the Zitadel identity provider.
+	// +kubebuilder:validation:MinItems=1
+	Include []AccessRule `json:"include"`
+
+	// Require lists rules every matching request must match in addition.
+	// +optional
+	Require []AccessRule `json:"require,omitempty"`
+
+	// Exclude lists rules that stop a request from matching.
+	// +optional
+	Exclude []AccessRule `json:"exclude,omitempty"`
+
+	// SessionDuration overrides the application's session duration for
+	// users admitted by this policy (e.g. "8h").
+	// +optional
+	SessionDuration string `json:"sessionDuration,omitempty"`
+}
+
+type AccessPolicyStatus struct {
+	// PolicyID is the Cloudflare Access policy ID.
+	PolicyID string `json:"policyId,omitempty"`
+
+	// ObservedGeneration is the spec generation that was last fully reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AccessPolicyList contains a list of AccessPolicy.
+type AccessPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AccessPolicy{}, &AccessPolicyList{})
+}
@@ -0,0 +1,78 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types set on SecuredApplication.status.conditions.
+//
+// Each managed resource has its own *Ready condition. Ready aggregates them:
+// it is True only when every resource was reconciled for the current
+// generation, so `kubectl wait --for=condition=Ready` can be used after an
+// apply. All conditions carry the generation they were computed for in
+// observedGeneration.
+const (
+	// ConditionReady is True when all resources are up to date.
+	ConditionReady = "Ready"
+
+	// ConditionZitadelAppReady covers the Zitadel project and role lookup,
+	// the OIDC application, and the credential Secret.
+	ConditionZitadelAppReady = "ZitadelAppReady"
+
+	// ConditionAccessApplicationReady covers the Cloudflare Access Application.
+	ConditionAccessApplicationReady = "AccessApplicationReady"
+
+	// ConditionAccessPolicyReady covers the Cloudflare Access policy.
+	ConditionAccessPolicyReady = "AccessPolicyReady"
+
+	// ConditionBypassAppsReady covers the bypass Access Applications.
+	ConditionBypassAppsReady = "BypassAppsReady"
+
+	// ConditionPathAppsReady covers the Access Applications and policies of
+	// spec.access.pathRules.
+	ConditionPathAppsReady = "PathAppsReady"
+
+	// ConditionServiceTokensReady covers the Cloudflare service tokens and
+	// their Secrets.
+	ConditionServiceTokensReady = "ServiceTokensReady"
+
+	// ConditionIngressReady covers the tunnel Ingress, HTTPRoute or tunnel
+	// rule and DNS record.
+	ConditionIngressReady = "IngressReady"
+
+	// ConditionOIDCIngressReady covers the native OIDC Ingress or HTTPRoute.
+	ConditionOIDCIngressReady = "OIDCIngressReady"
+
+	// ConditionStalled is True when an API rejected a request and it is only
+	// retried on the periodic resync or when the spec changes.
+	ConditionStalled = "Stalled"
+
+	// ConditionDrifted is True when the last reconcile found changes made in
+	// Cloudflare outside the operator: with reason DriftDetected until they
+	// are reverted, and DriftRepaired afterwards.
+	ConditionDrifted = "Drifted"
+
+	// ConditionCredentialsMissing is True while the credential Secret is
+	// being regenerated.
+	ConditionCredentialsMissing = "CredentialsMissing"
+
+	// ConditionHostConflict is True when another SecuredApplication claims
+	// one of this object's hosts first.
+	ConditionHostConflict = "HostConflict"
+)
+
+// StatusConditions returns the status conditions, so that the controllers
+// can set Ready and Stalled the same way for every kind.
+func (a *SecuredApplication) StatusConditions() *[]metav1.Condition {
+	return &a.Status.Conditions
+}
+
+// StatusConditions returns the status conditions.
+func (p *AccessPolicy) StatusConditions() *[]metav1.Condition {
+	return &p.Status.Conditions
+}
+
+// StatusConditions returns the status conditions.
+func (g *AccessGroup) StatusConditions() *[]metav1.Condition {
+	return &g.Status.Conditions
+}
@@ -6,10 +6,11 @@ import (
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
-// +kubebuilder:printcolumn:name="Host",type=string,JSONPath=`.spec.host`
+// +kubebuilder:printcolumn:name="Hosts",type=string,JSONPath=`.status.hosts`
 // +kubebuilder:printcolumn:name="Project",type=string,JSONPath=`.spec.access.project`
 // +kubebuilder:printcolumn:name="Client ID",type=string,JSONPath=`.status.clientId`
-// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // SecuredApplication registers an OIDC application in Zitadel, protects it
@@ -23,15 +24,42 @@ type SecuredApplication struct {
 	Status SecuredApplicationStatus `json:"status,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="has(self.host) || has(self.hosts)",message="one of host or hosts must be specified"
+// +kubebuilder:validation:XValidation:rule="has(self.backend) != has(self.routes)",message="exactly one of backend or routes must be specified"
 type SecuredApplicationSpec struct {
-	// Host is the public hostname for this application.
-	Host string `json:"host"`
+	// Host is the public hostname for this application. At least one of
+	// host and hosts must be set.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// Hosts lists further public hostnames for the same application, e.g.
+	// another domain. All hosts share one Access Application (one
+	// destination each), one Zitadel app (one redirect URI each) and one
+	// tunnel Ingress (one rule each).
+	// +listType=set
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:MinLength=1
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
 
 	// Access defines the Zitadel project and roles required to access this application.
 	Access Access `json:"access"`
 
-	// Backend defines the Kubernetes Service to route traffic to.
-	Backend Backend `json:"backend"`
+	// Backend defines the Kubernetes Service to route traffic to. Exactly
+	// one of backend and routes must be set.
+	// +optional
+	Backend *Backend `json:"backend,omitempty"`
+
+	// Routes sends requests to different Services by path, e.g. "/" to a
+	// frontend and "/api" to an API server. It replaces backend and the
+	// paths of ingress and nativeOIDC.ingress. All routes share one Access
+	// Application and Zitadel app.
+	// +listType=map
+	// +listMapKey=path
+	// +kubebuilder:validation:MinItems=1
+	// +optional
+	Routes []Route `json:"routes,omitempty"`
 
 	// NativeOIDC customizes the Zitadel OIDC application and optionally
 	// creates a second Ingress for native OIDC access.
@@ -50,10 +78,17 @@ type SecuredApplicationSpec struct {
 	// +optional
 	NativeOIDC *NativeOIDCConfig `json:"nativeOIDC,omitempty"`
 
-	// Ingress allows overriding generated Ingress settings.
+	// Ingress allows overriding generated Ingress settings. Path and
+	// pathType also apply to the HTTPRoute in HTTPRoute routing mode.
 	// +optional
 	Ingress *IngressConfig `json:"ingress,omitempty"`
 
+	// Routing selects whether traffic is routed with Ingress objects
+	// (default), Gateway API HTTPRoutes, or tunnel rules the operator
+	// manages itself.
+	// +optional
+	Routing *RoutingConfig `json:"routing,omitempty"`
+
 	// DeleteProtection prevents the operator from deleting external resources
 	// (Zitadel OIDC app, Cloudflare Access Application) when the CR is removed.
 	// Defaults to false.
@@ -75,6 +110,61 @@ type Access struct {
 	// +optional
 	Claims []ClaimCheck `json:"claims,omitempty"`
 
+	// Match is "any" (default) or "all". With "any" a user needs one of the
+	// roles or one of the claims. With "all" a user needs one of the roles
+	// and every claim; the claims are placed in the policy's require list.
+	// +kubebuilder:validation:Enum=any;all
+	// +optional
+	Match string `json:"match,omitempty"`
+
+	// Include lists further rules of the CF Access policy. A request is
+	// allowed if it matches any include rule (including roles and claims).
+	// +optional
+	Include []AccessRule `json:"include,omitempty"`
+
+	// Require lists rules every allowed request must match in addition.
+	// +optional
+	Require []AccessRule `json:"require,omitempty"`
+
+	// Exclude lists rules that deny a request even if it matches an include rule.
+	// +optional
+	Exclude []AccessRule `json:"exclude,omitempty"`
+
+	// Policies lists further Access policies of the application. They are
+	// evaluated after the policy generated from roles, claims and include
+	// rules, in list order unless precedence is set. Policies removed from
+	// the list are deleted.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Policies []AccessPolicyConfig `json:"policies,omitempty"`
+
+	// PolicyRefs lists the names of cluster-scoped AccessPolicy objects to
+	// attach to the application. They are evaluated after all other
+	// policies, in list order.
+	// +listType=set
+	// +optional
+	PolicyRefs []string `json:"policyRefs,omitempty"`
+
+	// ServiceTokens lists Cloudflare Access service tokens for machine
+	// clients such as CI jobs. Each token's credentials are written to a
+	// Secret, and a non_identity policy admitting all of them is added
+	// after the other policies.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	ServiceTokens []ServiceTokenConfig `json:"serviceTokens,omitempty"`
+
+	// PathRules protects path prefixes with their own rules, e.g. "/admin"
+	// with a stricter role set than the rest of the application. For each
+	// path, a separate CF Access Application is created with an allow
+	// policy built from the path's rules; Cloudflare applies the most
+	// specific path's application.
+	// +listType=map
+	// +listMapKey=path
+	// +optional
+	PathRules []PathRule `json:"pathRules,omitempty"`
+
 	// BypassPaths lists path prefixes that should bypass Cloudflare Access
 	// authentication. For each path, a separate CF Access Application is
 	// created with a "bypass" policy allowing unauthenticated access.
@@ -85,7 +175,7 @@ type Access struct {
 }
 
 // ClaimCheck defines an OIDC claim name/value pair for a Cloudflare Access policy rule.
-// At least one of roles or claims must be set on the parent Access struct.
+// At least one of roles, claims or include must be set on the parent Access struct.
 type ClaimCheck struct {
 	// Name is the OIDC claim name (e.g. "custom:department").
 	Name string `json:"name"`
@@ -94,6 +184,166 @@ type ClaimCheck struct {
 	Value string `json:"value"`
 }
 
+// AccessPolicyConfig is one Cloudflare Access policy of an application.
+type AccessPolicyConfig struct {
+	// Name of the policy. It must be unique within the application.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Decision is the action taken for matching requests.
+	// +kubebuilder:validation:Enum=allow;deny;bypass;non_identity
+	Decision string `json:"decision"`
+
+	// Precedence orders the policy among the application's policies (lower
+	// is evaluated first). Defaults to the position in the list, after the
+	// generated policy.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Precedence int `json:"precedence,omitempty"`
+
+	// Include lists the rules of which a request must match at least one.
+	// +kubebuilder:validation:MinItems=1
+	Include []AccessRule `json:"include"`
+
+	// Require lists rules every matching request must match in addition.
+	// +optional
+	Require []AccessRule `json:"require,omitempty"`
+
+	// Exclude lists rules that stop a request from matching.
+	// +optional
+	Exclude []AccessRule `json:"exclude,omitempty"`
+
+	// SessionDuration overrides the application's session duration for
+	// users admitted by this policy (e.g. "8h").
+	// +optional
+	SessionDuration string `json:"sessionDuration,omitempty"`
+}
+
+// PathRule is the access rule set of one path prefix. Roles, claims, match
+// and the rule lists work as on Access; at least one of roles, claims or
+// include must be set.
+type PathRule struct {
+	// Path is the path prefix, e.g. "/admin".
+	Path string `json:"path"`
+
+	// Roles lists the Zitadel project roles allowed to access the path.
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+
+	// Claims defines OIDC claim checks for the path.
+	// +optional
+	Claims []ClaimCheck `json:"claims,omitempty"`
+
+	// Match is "any" (default) or "all", as on spec.access.
+	// +kubebuilder:validation:Enum=any;all
+	// +optional
+	Match string `json:"match,omitempty"`
+
+	// Include lists further rules of which a request must match at least one.
+	// +optional
+	Include []AccessRule `json:"include,omitempty"`
+
+	// Require lists rules every allowed request must match in addition.
+	// +optional
+	Require []AccessRule `json:"require,omitempty"`
+
+	// Exclude lists rules that deny a request even if it matches an include rule.
+	// +optional
+	Exclude []AccessRule `json:"exclude,omitempty"`
+}
+
+// AccessRule is one Cloudflare Access rule selector. Exactly one field must be set.
+// +kubebuilder:validation:MinProperties=1
+// +kubebuilder:validation:MaxProperties=1
+type AccessRule struct {
+	// Role matches users with this Zitadel project role (custom:roles claim).
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// Claim matches an OIDC claim of the Zitadel identity provider.
+	// +optional
+	Claim *ClaimCheck `json:"claim,omitempty"`
+
+	// Email matches a single email address.
+	// +optional
+	Email string `json:"email,omitempty"`
+
+	// EmailDomain matches every email address of a domain (e.g. "example.com").
+	// +optional
+	EmailDomain string `json:"emailDomain,omitempty"`
+
+	// IP matches a client IP address or CIDR range (e.g. "10.0.0.0/8").
+	// +optional
+	IP string `json:"ip,omitempty"`
+
+	// Country matches the client's ISO 3166-1 alpha-2 country code (e.g. "DE").
+	// +kubebuilder:validation:Pattern=`^[A-Z]{2}$`
+	// +optional
+	Country string `json:"country,omitempty"`
+
+	// GroupID matches members of the Cloudflare Access group with this ID.
+	// +optional
+	GroupID string `json:"groupId,omitempty"`
+
+	// GroupRef matches members of the AccessGroup with this name.
+	// +optional
+	GroupRef string `json:"groupRef,omitempty"`
+
+	// ServiceTokenID matches the Cloudflare Access service token with this ID.
+	// +optional
+	ServiceTokenID string `json:"serviceTokenId,omitempty"`
+
+	// AnyValidServiceToken matches any service token of the account.
+	// +optional
+	AnyValidServiceToken bool `json:"anyValidServiceToken,omitempty"`
+
+	// Certificate matches any valid mTLS client certificate.
+	// +optional
+	Certificate bool `json:"certificate,omitempty"`
+
+	// CommonName matches the common name of an mTLS client certificate.
+	// +optional
+	CommonName string `json:"commonName,omitempty"`
+
+	// DevicePosture matches devices passing the device posture check with
+	// this integration UID.
+	// +optional
+	DevicePosture string `json:"devicePosture,omitempty"`
+}
+
+type ServiceTokenConfig struct {
+	// Name identifies the token. The Cloudflare token is named
+	// "{metadata.name}-{name}".
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Duration is how long the token is valid (e.g. "8760h"). The token is
+	// refreshed and its secret rotated once less than a tenth of it
+	// remains. Defaults to one year.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// SecretName is the Secret receiving the CF-Access-Client-Id and
+	// CF-Access-Client-Secret keys. Defaults to
+	// "{metadata.name}-{name}-service-token".
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// Route sends the requests matching a path to a Service.
+type Route struct {
+	// Path is matched against the request path, e.g. "/api".
+	Path string `json:"path"`
+
+	// PathType defaults to "Prefix".
+	// +optional
+	PathType string `json:"pathType,omitempty"`
+
+	// Backend is the Service matching requests are sent to.
+	Backend Backend `json:"backend"`
+}
+
 type Backend struct {
 	// ServiceName is the name of the Kubernetes Service.
 	ServiceName string `json:"serviceName"`
@@ -161,6 +411,14 @@ type NativeOIDCConfig struct {
 	// +optional
 	ClientSecretRef string `json:"clientSecretRef,omitempty"`
 
+	// SecretRotationInterval regenerates the Zitadel client secret and
+	// rewrites the credential Secret once this long has passed since the
+	// last rotation (e.g. "720h"). A one-off rotation can be requested at any
+	// time by setting the access.twiechert.de/rotate-secret annotation to a
+	// new value.
+	// +optional
+	SecretRotationInterval *metav1.Duration `json:"secretRotationInterval,omitempty"`
+
 	// Ingress creates a second Ingress that bypasses Cloudflare Access,
 	// allowing the app to handle authentication directly via its Zitadel
 	// OIDC credentials. Requires a host different from spec.host.
@@ -173,7 +431,9 @@ type OIDCIngressConfig struct {
 	Host string `json:"host"`
 
 	// ClassName for the direct Ingress (e.g. "nginx"). No default.
-	ClassName string `json:"className"`
+	// Required in Ingress routing mode, ignored for HTTPRoutes.
+	// +optional
+	ClassName string `json:"className,omitempty"`
 
 	// Annotations to add to the generated Ingress.
 	// +optional
@@ -206,7 +466,53 @@ type IngressConfig struct {
 	PathType string `json:"pathType,omitempty"`
 }
 
+type RoutingConfig struct {
+	// Mode is "Ingress" (default), "HTTPRoute" or "Tunnel". HTTPRoute mode
+	// requires the operator to run with --enable-gateway-api. In Tunnel mode
+	// the operator adds the spec.host rule to a Cloudflare Tunnel's
+	// configuration and creates the proxied CNAME record itself, instead of
+	// relying on an ingress controller; the native OIDC route is still an
+	// Ingress.
+	// +kubebuilder:validation:Enum=Ingress;HTTPRoute;Tunnel
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// TunnelName is the remotely managed Cloudflare Tunnel to route through
+	// in Tunnel mode. Defaults to the operator's --cloudflare-tunnel-name.
+	// +optional
+	TunnelName string `json:"tunnelName,omitempty"`
+
+	// Gateway is the parent Gateway of the HTTPRoute for spec.host.
+	// Required in HTTPRoute mode.
+	// +optional
+	Gateway *GatewayRef `json:"gateway,omitempty"`
+
+	// OIDCGateway is the parent Gateway of the HTTPRoute for
+	// nativeOIDC.ingress.host. Defaults to gateway.
+	// +optional
+	OIDCGateway *GatewayRef `json:"oidcGateway,omitempty"`
+}
+
+// GatewayRef references a Gateway API Gateway and optionally one of its listeners.
+type GatewayRef struct {
+	// Name of the Gateway.
+	Name string `json:"name"`
+
+	// Namespace of the Gateway. Defaults to the SecuredApplication's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName selects a listener on the Gateway.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
 type SecuredApplicationStatus struct {
+	// Hosts lists every hostname the application claims: spec.host,
+	// spec.hosts and the native OIDC host.
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
 	// ProjectID is the resolved Zitadel project ID.
 	ProjectID string `json:"projectId,omitempty"`
 
@@ -222,16 +528,92 @@ type SecuredApplicationStatus struct {
 	// AccessPolicyID is the Cloudflare Access Policy ID.
 	AccessPolicyID string `json:"accessPolicyId,omitempty"`
 
+	// AccessPolicyIDs maps the name of each spec.access.policies entry →
+	// CF Access Policy ID.
+	AccessPolicyIDs map[string]string `json:"accessPolicyIds,omitempty"`
+
+	// ReusablePolicyIDs maps the name of each attached AccessPolicy → CF
+	// Access Policy ID.
+	ReusablePolicyIDs map[string]string `json:"reusablePolicyIds,omitempty"`
+
 	// BypassApplicationIDs maps bypass path → CF Access Application ID.
 	BypassApplicationIDs map[string]string `json:"bypassApplicationIds,omitempty"`
 
-	// Ready indicates the application is fully reconciled.
+	// PathApplicationIDs maps each spec.access.pathRules path → CF Access
+	// Application ID.
+	// +optional
+	PathApplicationIDs map[string]string `json:"pathApplicationIds,omitempty"`
+
+	// PathPolicyIDs maps each spec.access.pathRules path → CF Access Policy
+	// ID of its application.
+	// +optional
+	PathPolicyIDs map[string]string `json:"pathPolicyIds,omitempty"`
+
+	// ServiceTokens records the Cloudflare service tokens by name.
+	// +optional
+	ServiceTokens map[string]ServiceTokenStatus `json:"serviceTokens,omitempty"`
+
+	// TunnelRoutes records the tunnel rules and DNS records created in
+	// Tunnel routing mode.
+	// +optional
+	TunnelRoutes []TunnelRouteStatus `json:"tunnelRoutes,omitempty"`
+
+	// LastSecretRotation is when the client secret was last generated.
+	// +optional
+	LastSecretRotation *metav1.Time `json:"lastSecretRotation,omitempty"`
+
+	// LastRotationRequest is the value of the access.twiechert.de/rotate-secret
+	// annotation that was last acted on.
+	// +optional
+	LastRotationRequest string `json:"lastRotationRequest,omitempty"`
+
+	// ObservedGeneration is the spec generation that was last fully reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Plan lists the changes the operator would make. It is only set in
+	// dry-run mode (--dry-run or the access.twiechert.de/dry-run annotation).
+	// +optional
+	Plan []string `json:"plan,omitempty"`
+
+	// Ready indicates the application is fully reconciled. It mirrors the
+	// Ready condition and is kept for compatibility.
 	Ready bool `json:"ready"`
 
 	// Conditions represent the latest available observations.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+type ServiceTokenStatus struct {
+	// ID is the Cloudflare service token ID.
+	ID string `json:"id"`
+
+	// ClientID is the token's CF-Access-Client-Id.
+	ClientID string `json:"clientId,omitempty"`
+
+	// SecretName is the Secret holding the token's credentials.
+	SecretName string `json:"secretName,omitempty"`
+
+	// ExpiresAt is when the token expires unless it is refreshed.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+type TunnelRouteStatus struct {
+	// Hostname is the public hostname routed through the tunnel.
+	Hostname string `json:"hostname"`
+
+	// TunnelID is the Cloudflare Tunnel whose configuration has the rule.
+	TunnelID string `json:"tunnelId"`
+
+	// ZoneID is the Cloudflare zone of the DNS record.
+	// +optional
+	ZoneID string `json:"zoneId,omitempty"`
+
+	// DNSRecordID is the proxied CNAME record pointing at the tunnel.
+	// +optional
+	DNSRecordID string `json:"dnsRecordId,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 
 // SecuredApplicationList contains a list of SecuredApplication.
@@ -22,6 +22,53 @@ func (in *Access) DeepCopyInto(out *Access) {
 		*out = make([]ClaimCheck, len(*in))
 		copy(*out, *in)
 	}
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Require != nil {
+		in, out := &in.Require, &out.Require
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]AccessPolicyConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PolicyRefs != nil {
+		in, out := &in.PolicyRefs, &out.PolicyRefs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceTokens != nil {
+		in, out := &in.ServiceTokens, &out.ServiceTokens
+		*out = make([]ServiceTokenConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PathRules != nil {
+		in, out := &in.PathRules, &out.PathRules
+		*out = make([]PathRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.BypassPaths != nil {
 		in, out := &in.BypassPaths, &out.BypassPaths
 		*out = make([]string, len(*in))
@@ -39,6 +86,296 @@ func (in *Access) DeepCopy() *Access {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessGroup) DeepCopyInto(out *AccessGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessGroup.
+func (in *AccessGroup) DeepCopy() *AccessGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessGroupList) DeepCopyInto(out *AccessGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AccessGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessGroupList.
+func (in *AccessGroupList) DeepCopy() *AccessGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessGroupSpec) DeepCopyInto(out *AccessGroupSpec) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Require != nil {
+		in, out := &in.Require, &out.Require
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessGroupSpec.
+func (in *AccessGroupSpec) DeepCopy() *AccessGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessGroupStatus) DeepCopyInto(out *AccessGroupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessGroupStatus.
+func (in *AccessGroupStatus) DeepCopy() *AccessGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessPolicy) DeepCopyInto(out *AccessPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessPolicy.
+func (in *AccessPolicy) DeepCopy() *AccessPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessPolicyConfig) DeepCopyInto(out *AccessPolicyConfig) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Require != nil {
+		in, out := &in.Require, &out.Require
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessPolicyConfig.
+func (in *AccessPolicyConfig) DeepCopy() *AccessPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessPolicyList) DeepCopyInto(out *AccessPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AccessPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessPolicyList.
+func (in *AccessPolicyList) DeepCopy() *AccessPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessPolicySpec) DeepCopyInto(out *AccessPolicySpec) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Require != nil {
+		in, out := &in.Require, &out.Require
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessPolicySpec.
+func (in *AccessPolicySpec) DeepCopy() *AccessPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessPolicyStatus) DeepCopyInto(out *AccessPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessPolicyStatus.
+func (in *AccessPolicyStatus) DeepCopy() *AccessPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRule) DeepCopyInto(out *AccessRule) {
+	*out = *in
+	if in.Claim != nil {
+		in, out := &in.Claim, &out.Claim
+		*out = new(ClaimCheck)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRule.
+func (in *AccessRule) DeepCopy() *AccessRule {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Backend) DeepCopyInto(out *Backend) {
 	*out = *in
@@ -69,6 +406,21 @@ func (in *ClaimCheck) DeepCopy() *ClaimCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayRef) DeepCopyInto(out *GatewayRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayRef.
+func (in *GatewayRef) DeepCopy() *GatewayRef {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressConfig) DeepCopyInto(out *IngressConfig) {
 	*out = *in
@@ -104,6 +456,11 @@ func (in *NativeOIDCConfig) DeepCopyInto(out *NativeOIDCConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SecretRotationInterval != nil {
+		in, out := &in.SecretRotationInterval, &out.SecretRotationInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.Ingress != nil {
 		in, out := &in.Ingress, &out.Ingress
 		*out = new(OIDCIngressConfig)
@@ -143,6 +500,93 @@ func (in *OIDCIngressConfig) DeepCopy() *OIDCIngressConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PathRule) DeepCopyInto(out *PathRule) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Claims != nil {
+		in, out := &in.Claims, &out.Claims
+		*out = make([]ClaimCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Require != nil {
+		in, out := &in.Require, &out.Require
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]AccessRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PathRule.
+func (in *PathRule) DeepCopy() *PathRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PathRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Route) DeepCopyInto(out *Route) {
+	*out = *in
+	out.Backend = in.Backend
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
+func (in *Route) DeepCopy() *Route {
+	if in == nil {
+		return nil
+	}
+	out := new(Route)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoutingConfig) DeepCopyInto(out *RoutingConfig) {
+	*out = *in
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewayRef)
+		**out = **in
+	}
+	if in.OIDCGateway != nil {
+		in, out := &in.OIDCGateway, &out.OIDCGateway
+		*out = new(GatewayRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoutingConfig.
+func (in *RoutingConfig) DeepCopy() *RoutingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RoutingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecuredApplication) DeepCopyInto(out *SecuredApplication) {
 	*out = *in
@@ -205,8 +649,22 @@ func (in *SecuredApplicationList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecuredApplicationSpec) DeepCopyInto(out *SecuredApplicationSpec) {
 	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.Access.DeepCopyInto(&out.Access)
-	out.Backend = in.Backend
+	if in.Backend != nil {
+		in, out := &in.Backend, &out.Backend
+		*out = new(Backend)
+		**out = **in
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]Route, len(*in))
+		copy(*out, *in)
+	}
 	if in.NativeOIDC != nil {
 		in, out := &in.NativeOIDC, &out.NativeOIDC
 		*out = new(NativeOIDCConfig)
@@ -217,6 +675,11 @@ func (in *SecuredApplicationSpec) DeepCopyInto(out *SecuredApplicationSpec) {
 		*out = new(IngressConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Routing != nil {
+		in, out := &in.Routing, &out.Routing
+		*out = new(RoutingConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecuredApplicationSpec.
@@ -232,6 +695,25 @@ func (in *SecuredApplicationSpec) DeepCopy() *SecuredApplicationSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecuredApplicationStatus) DeepCopyInto(out *SecuredApplicationStatus) {
 	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AccessPolicyIDs != nil {
+		in, out := &in.AccessPolicyIDs, &out.AccessPolicyIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ReusablePolicyIDs != nil {
+		in, out := &in.ReusablePolicyIDs, &out.ReusablePolicyIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.BypassApplicationIDs != nil {
 		in, out := &in.BypassApplicationIDs, &out.BypassApplicationIDs
 		*out = make(map[string]string, len(*in))
@@ -239,6 +721,41 @@ func (in *SecuredApplicationStatus) DeepCopyInto(out *SecuredApplicationStatus)
 			(*out)[key] = val
 		}
 	}
+	if in.PathApplicationIDs != nil {
+		in, out := &in.PathApplicationIDs, &out.PathApplicationIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PathPolicyIDs != nil {
+		in, out := &in.PathPolicyIDs, &out.PathPolicyIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ServiceTokens != nil {
+		in, out := &in.ServiceTokens, &out.ServiceTokens
+		*out = make(map[string]ServiceTokenStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.TunnelRoutes != nil {
+		in, out := &in.TunnelRoutes, &out.TunnelRoutes
+		*out = make([]TunnelRouteStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastSecretRotation != nil {
+		in, out := &in.LastSecretRotation, &out.LastSecretRotation
+		*out = (*in).DeepCopy()
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -257,3 +774,57 @@ func (in *SecuredApplicationStatus) DeepCopy() *SecuredApplicationStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceTokenConfig) DeepCopyInto(out *ServiceTokenConfig) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceTokenConfig.
+func (in *ServiceTokenConfig) DeepCopy() *ServiceTokenConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceTokenConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceTokenStatus) DeepCopyInto(out *ServiceTokenStatus) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceTokenStatus.
+func (in *ServiceTokenStatus) DeepCopy() *ServiceTokenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceTokenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelRouteStatus) DeepCopyInto(out *TunnelRouteStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelRouteStatus.
+func (in *TunnelRouteStatus) DeepCopy() *TunnelRouteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelRouteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -60,3 +60,10 @@ Secret name — either the user-supplied existing secret or the chart-managed on
 {{- include "cf-zitadel-access-operator.fullname" . }}
 {{- end }}
 {{- end }}
+
+{{/*
+Webhook serving certificate Secret name.
+*/}}
+{{- define "cf-zitadel-access-operator.webhookCertSecret" -}}
+{{- default (printf "%s-webhook-cert" (include "cf-zitadel-access-operator.fullname" .)) .Values.webhook.certSecret }}
+{{- end }}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -10,10 +12,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
 	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
 	"github.com/twiechert/cf-zitadel-access-operator/internal/controller"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/metrics"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/webhook"
 	"github.com/twiechert/cf-zitadel-access-operator/internal/zitadel"
 )
 
@@ -25,23 +31,43 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		if err := render(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var (
 		metricsAddr          string
 		probeAddr            string
 		enableLeaderElection bool
 		zitadelURL           string
+		zitadelKeyFile       string
 		cfAccountID          string
 		cfIdPID              string
+		cfTunnelName         string
 		sessionDuration      string
+		resyncInterval       time.Duration
+		enableGatewayAPI     bool
+		enableWebhooks       bool
+		dryRun               bool
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metrics endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the health probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Enable leader election.")
 	flag.StringVar(&zitadelURL, "zitadel-url", os.Getenv("ZITADEL_URL"), "Base URL of the Zitadel instance.")
+	flag.StringVar(&zitadelKeyFile, "zitadel-key-file", os.Getenv("ZITADEL_KEY_FILE"), "Path to a Zitadel machine-user key JSON. Takes precedence over ZITADEL_TOKEN.")
 	flag.StringVar(&cfAccountID, "cloudflare-account-id", os.Getenv("CLOUDFLARE_ACCOUNT_ID"), "Cloudflare account ID.")
 	flag.StringVar(&cfIdPID, "cloudflare-idp-id", os.Getenv("CLOUDFLARE_IDP_ID"), "Cloudflare Access Identity Provider ID for Zitadel.")
+	flag.StringVar(&cfTunnelName, "cloudflare-tunnel-name", os.Getenv("CLOUDFLARE_TUNNEL_NAME"), "Default Cloudflare Tunnel for Tunnel routing mode.")
 	flag.StringVar(&sessionDuration, "session-duration", "24h", "Cloudflare Access session duration.")
+	flag.BoolVar(&enableGatewayAPI, "enable-gateway-api", false, "Allow HTTPRoute routing mode. Requires the Gateway API CRDs.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the validating admission webhook. Requires a serving certificate.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only report the changes the operator would make in status.plan and Events.")
+	flag.DurationVar(&resyncInterval, "resync-interval", 10*time.Minute, "How often to check Cloudflare for drift. 0 disables periodic resync.")
 
 	// Sensitive values — env-only, never exposed as CLI flags.
 	zitadelToken := os.Getenv("ZITADEL_TOKEN")
@@ -54,8 +80,8 @@ func main() {
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 	setupLog := ctrl.Log.WithName("setup")
 
-	if zitadelURL == "" || zitadelToken == "" {
-		setupLog.Error(nil, "ZITADEL_URL and ZITADEL_TOKEN env vars are required")
+	if zitadelURL == "" || (zitadelToken == "" && zitadelKeyFile == "") {
+		setupLog.Error(nil, "ZITADEL_URL and one of ZITADEL_KEY_FILE or ZITADEL_TOKEN are required")
 		os.Exit(1)
 	}
 	if cfAPIToken == "" || cfAccountID == "" || cfIdPID == "" {
@@ -63,8 +89,23 @@ func main() {
 		os.Exit(1)
 	}
 
+	var zitadelTokens zitadel.TokenSource = zitadel.StaticToken(zitadelToken)
+	if zitadelKeyFile != "" {
+		keyJSON, err := os.ReadFile(zitadelKeyFile)
+		if err != nil {
+			setupLog.Error(err, "unable to read Zitadel key file", "path", zitadelKeyFile)
+			os.Exit(1)
+		}
+		zitadelTokens, err = zitadel.NewJWTProfileTokenSource(zitadelURL, keyJSON)
+		if err != nil {
+			setupLog.Error(err, "invalid Zitadel key file", "path", zitadelKeyFile)
+			os.Exit(1)
+		}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: metricsAddr},
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "cf-zitadel-access-operator.access.twiechert.de",
@@ -77,18 +118,54 @@ func main() {
 	reconciler := &controller.SecuredApplicationReconciler{
 		Client:     mgr.GetClient(),
 		Scheme:     mgr.GetScheme(),
-		Zitadel:    zitadel.NewClient(zitadelURL, zitadelToken),
+		Zitadel:    zitadel.NewClient(zitadelURL, zitadelTokens),
 		Cloudflare: cfclient.NewClient(cfAPIToken, cfAccountID),
 		Config: controller.Config{
-			CloudflareIdPID: cfIdPID,
-			SessionDuration: sessionDuration,
+			CloudflareIdPID:  cfIdPID,
+			TunnelName:       cfTunnelName,
+			SessionDuration:  sessionDuration,
+			ResyncInterval:   resyncInterval,
+			EnableGatewayAPI: enableGatewayAPI,
+			DryRun:           dryRun,
 		},
+		Recorder: mgr.GetEventRecorder("cf-zitadel-access-operator"),
 	}
 	if err := reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SecuredApplication")
 		os.Exit(1)
 	}
 
+	if err := (&controller.AccessPolicyReconciler{
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Cloudflare: reconciler.Cloudflare,
+		Config:     reconciler.Config,
+		Recorder:   mgr.GetEventRecorder("cf-zitadel-access-operator"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AccessPolicy")
+		os.Exit(1)
+	}
+
+	if err := (&controller.AccessGroupReconciler{
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Cloudflare: reconciler.Cloudflare,
+		Config:     reconciler.Config,
+		Recorder:   mgr.GetEventRecorder("cf-zitadel-access-operator"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AccessGroup")
+		os.Exit(1)
+	}
+
+	ctrlmetrics.Registry.MustRegister(metrics.NewSecuredApplicationCollector(mgr.GetClient()))
+
+	if enableWebhooks {
+		if err := (&webhook.SecuredApplicationValidator{Client: mgr.GetClient()}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SecuredApplication")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
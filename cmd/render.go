@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// render prints what the operator would send to Zitadel and Cloudflare and
+// apply to Kubernetes for each SecuredApplication, AccessPolicy and
+// AccessGroup in a file, without contacting any API. The output is a YAML
+// stream with one document per request body or object, so it can be diffed
+// or used as a golden file.
+func render(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	file := fs.String("f", "", "File containing SecuredApplication, AccessPolicy and AccessGroup manifests, or - for stdin.")
+	cfIdPID := fs.String("cloudflare-idp-id", os.Getenv("CLOUDFLARE_IDP_ID"), "Cloudflare Access Identity Provider ID for Zitadel.")
+	sessionDuration := fs.String("session-duration", "24h", "Cloudflare Access session duration.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f is required")
+	}
+
+	var in io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("open manifest: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// AccessGroup and service token IDs are only known once they exist, so
+	// rules referencing them render with a placeholder ID.
+	return renderManifests(os.Stdout, in, builder.Refs{IdPID: *cfIdPID}, *sessionDuration)
+}
+
+// renderManifests renders every manifest read from in to w.
+func renderManifests(w io.Writer, in io.Reader, refs builder.Refs, sessionDuration string) error {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(in, 4096)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("decode manifest: %w", err)
+		}
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(raw, &typeMeta); err != nil {
+			return fmt.Errorf("decode manifest: %w", err)
+		}
+
+		switch typeMeta.Kind {
+		case "AccessPolicy":
+			var policy accessv1alpha1.AccessPolicy
+			if err := json.Unmarshal(raw, &policy); err != nil {
+				return fmt.Errorf("decode manifest: %w", err)
+			}
+			if err := writeJSON(w, "Cloudflare reusable Access policy "+policy.Name, builder.ReusablePolicy(&policy, refs)); err != nil {
+				return fmt.Errorf("render AccessPolicy %s: %w", policy.Name, err)
+			}
+		case "AccessGroup":
+			var group accessv1alpha1.AccessGroup
+			if err := json.Unmarshal(raw, &group); err != nil {
+				return fmt.Errorf("decode manifest: %w", err)
+			}
+			if err := writeJSON(w, "Cloudflare Access group "+group.Name, builder.Group(&group, refs)); err != nil {
+				return fmt.Errorf("render AccessGroup %s: %w", group.Name, err)
+			}
+		case "SecuredApplication", "":
+			var app accessv1alpha1.SecuredApplication
+			if err := json.Unmarshal(raw, &app); err != nil {
+				return fmt.Errorf("decode manifest: %w", err)
+			}
+			if app.Name == "" {
+				continue
+			}
+			if app.Namespace == "" {
+				app.Namespace = "default"
+			}
+			if err := renderApp(w, &app, refs, sessionDuration); err != nil {
+				return fmt.Errorf("render %s/%s: %w", app.Namespace, app.Name, err)
+			}
+		}
+	}
+}
+
+func renderApp(w io.Writer, app *accessv1alpha1.SecuredApplication, refs builder.Refs, sessionDuration string) error {
+	// The CRD schema guarantees these on the cluster.
+	if len(builder.Hosts(app)) == 0 {
+		return errors.New("one of host or hosts must be specified")
+	}
+	if (app.Spec.Backend == nil) == (len(app.Spec.Routes) == 0) {
+		return errors.New("exactly one of backend or routes must be specified")
+	}
+
+	if err := writeJSON(w, "Zitadel OIDC app", builder.ZitadelAppConfig(app)); err != nil {
+		return err
+	}
+
+	for i := range app.Spec.Access.ServiceTokens {
+		name, duration := builder.ServiceToken(app, &app.Spec.Access.ServiceTokens[i])
+		if err := writeJSON(w, "Cloudflare service token", cfclient.ServiceTokenBody(name, duration.String())); err != nil {
+			return err
+		}
+	}
+
+	desired := builder.AccessApp(app, refs, sessionDuration)
+	if err := writeJSON(w, "Cloudflare Access Application", cfclient.AccessAppBody(desired.Name, desired.Domains, desired.SessionDuration)); err != nil {
+		return err
+	}
+	if builder.HasAllowPolicy(app) {
+		if err := writeJSON(w, "Cloudflare Access policy", cfclient.AllowPolicyBody(desired.Policy)); err != nil {
+			return err
+		}
+	}
+	for _, policy := range builder.AccessPolicies(app, refs) {
+		if err := writeJSON(w, "Cloudflare Access policy "+policy.Name, policy); err != nil {
+			return err
+		}
+	}
+	for _, bypass := range builder.BypassApps(app) {
+		if err := writeJSON(w, "Cloudflare bypass Access Application", cfclient.BypassAppBody(bypass.Name, bypass.Domains)); err != nil {
+			return err
+		}
+		if err := writeJSON(w, "Cloudflare bypass Access policy", cfclient.BypassPolicyBody()); err != nil {
+			return err
+		}
+	}
+	for _, pathApp := range builder.PathApps(app, refs, sessionDuration) {
+		if err := writeJSON(w, "Cloudflare Access Application for "+pathApp.Path, cfclient.AccessAppBody(pathApp.App.Name, pathApp.App.Domains, pathApp.App.SessionDuration)); err != nil {
+			return err
+		}
+		if err := writeJSON(w, "Cloudflare Access policy for "+pathApp.Path, cfclient.AllowPolicyBody(pathApp.App.Policy)); err != nil {
+			return err
+		}
+	}
+
+	wantOIDC := app.Spec.NativeOIDC != nil && app.Spec.NativeOIDC.Ingress != nil
+	if app.Spec.Routing != nil && app.Spec.Routing.Mode == "HTTPRoute" {
+		if app.Spec.Routing.Gateway == nil {
+			return errors.New("routing.gateway is required in HTTPRoute mode")
+		}
+		if err := writeYAML(w, "HTTPRoute", builder.TunnelHTTPRoute(app)); err != nil {
+			return err
+		}
+		if wantOIDC {
+			return writeYAML(w, "OIDC HTTPRoute", builder.OIDCHTTPRoute(app))
+		}
+		return nil
+	}
+
+	if app.Spec.Routing != nil && app.Spec.Routing.Mode == "Tunnel" {
+		// The tunnel ID is only known once the tunnel is looked up.
+		for _, rule := range builder.TunnelIngressRules(app) {
+			if err := writeJSON(w, "Cloudflare Tunnel ingress rule", rule); err != nil {
+				return err
+			}
+		}
+		for _, host := range builder.Hosts(app) {
+			record := cfclient.DNSRecord{Type: "CNAME", Name: host, Content: "<tunnel ID>.cfargotunnel.com", Proxied: true, TTL: 1}
+			if err := writeJSON(w, "Cloudflare DNS record", record); err != nil {
+				return err
+			}
+		}
+	} else if err := writeYAML(w, "Ingress", builder.Ingress(app)); err != nil {
+		return err
+	}
+	if wantOIDC {
+		return writeYAML(w, "OIDC Ingress", builder.OIDCIngress(app))
+	}
+	return nil
+}
+
+// writeJSON prints v as an indented JSON document. HTML escaping is off so
+// that placeholders such as "<AccessGroup on-call>" stay readable.
+func writeJSON(w io.Writer, title string, v any) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("marshal %s: %w", title, err)
+	}
+	_, err := fmt.Fprintf(w, "---\n# %s\n%s", title, b.Bytes())
+	return err
+}
+
+// writeYAML prints obj as a YAML document without the empty
+// creationTimestamp and status fields typed objects marshal to.
+func writeYAML(w io.Writer, title string, obj runtime.Object) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return fmt.Errorf("convert %s: %w", title, err)
+	}
+	unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(content, "status")
+
+	b, err := yaml.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", title, err)
+	}
+	_, err = fmt.Fprintf(w, "---\n# %s\n%s", title, b)
+	return err
+}
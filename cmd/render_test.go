@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/render")
+
+// TestRenderGolden renders each testdata/render/*.yaml fixture and compares
+// the output with the .golden file next to it. Run with -update to rewrite
+// the golden files after an intended change.
+func TestRenderGolden(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "render", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata/render")
+	}
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".yaml")
+		t.Run(name, func(t *testing.T) {
+			in, err := os.Open(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+
+			var out bytes.Buffer
+			if err := renderManifests(&out, in, builder.Refs{IdPID: "idp1"}, "24h"); err != nil {
+				t.Fatalf("render: %v", err)
+			}
+
+			golden := strings.TrimSuffix(fixture, ".yaml") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("output differs from %s (run with -update to accept):\n%s", golden, out.Bytes())
+			}
+		})
+	}
+}
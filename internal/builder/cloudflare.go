@@ -0,0 +1,302 @@
+package builder
+
+import (
+	"fmt"
+	"time"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// The custom:roles claim is a flat array produced by a Zitadel Action (flatRoles).
+// Cloudflare Access can't match Zitadel's default nested role claim format.
+const RoleClaimName = "custom:roles"
+
+// matchAll is the spec.access.match value that requires every claim.
+const matchAll = "all"
+
+// ServiceTokenPolicyName is the name of the non_identity policy admitting
+// the service tokens in spec.access.serviceTokens.
+const ServiceTokenPolicyName = "service-tokens"
+
+// Refs holds the IDs that rules refer to outside the spec.
+type Refs struct {
+	// IdPID is the Cloudflare identity provider ID of Zitadel, used by role
+	// and claim rules.
+	IdPID string
+
+	// GroupIDs maps AccessGroup names to Cloudflare group IDs, used by
+	// groupRef rules.
+	GroupIDs map[string]string
+
+	// ServiceTokenIDs maps spec.access.serviceTokens names to Cloudflare
+	// service token IDs.
+	ServiceTokenIDs map[string]string
+}
+
+// groupID returns the Cloudflare ID of the named AccessGroup. Names that
+// were not resolved, e.g. when rendering offline, yield a placeholder.
+func (r Refs) groupID(name string) string {
+	if id, ok := r.GroupIDs[name]; ok {
+		return id
+	}
+	return "<AccessGroup " + name + ">"
+}
+
+// serviceTokenID returns the Cloudflare ID of the named service token, or a
+// placeholder like groupID.
+func (r Refs) serviceTokenID(name string) string {
+	if id, ok := r.ServiceTokenIDs[name]; ok {
+		return id
+	}
+	return "<service token " + name + ">"
+}
+
+// AccessApp builds the Access Application and allow policy for app. Roles
+// and spec.access.include form the include list. Claims are included too, or
+// with match "all" required.
+func AccessApp(app *accessv1alpha1.SecuredApplication, refs Refs, sessionDuration string) cfclient.DesiredAccessApp {
+	access := app.Spec.Access
+	return cfclient.DesiredAccessApp{
+		Name:            app.Name,
+		Domains:         Hosts(app),
+		SessionDuration: sessionDuration,
+		Policy:          policyRules(access.Roles, access.Claims, access.Match, access.Include, access.Require, access.Exclude, refs),
+	}
+}
+
+// policyRules builds the rules of an allow policy from the rule model shared
+// by spec.access and spec.access.pathRules.
+func policyRules(roleNames []string, claimChecks []accessv1alpha1.ClaimCheck, match string, includeRules, requireRules, excludeRules []accessv1alpha1.AccessRule, refs Refs) cfclient.PolicyRules {
+	var roles, claims []cfclient.Rule
+	for _, role := range roleNames {
+		roles = append(roles, roleRule(refs.IdPID, role))
+	}
+	for _, claim := range claimChecks {
+		claims = append(claims, claimRule(refs.IdPID, claim))
+	}
+
+	var include, require []cfclient.Rule
+	if match == matchAll {
+		include = append(roles, Rules(includeRules, refs)...)
+		// Cloudflare needs at least one include rule. Without one, a user
+		// holding every claim also holds the first.
+		if len(include) == 0 && len(claims) > 0 {
+			include = []cfclient.Rule{claims[0]}
+		}
+		require = append(claims, Rules(requireRules, refs)...)
+	} else {
+		include = append(append(roles, claims...), Rules(includeRules, refs)...)
+		require = Rules(requireRules, refs)
+	}
+
+	return cfclient.PolicyRules{
+		Include: include,
+		Require: require,
+		Exclude: Rules(excludeRules, refs),
+	}
+}
+
+// HasAllowPolicy reports whether app gets the allow policy generated from
+// roles, claims and include rules. Cloudflare rejects policies without an
+// include rule, so it is skipped when there are none.
+func HasAllowPolicy(app *accessv1alpha1.SecuredApplication) bool {
+	access := app.Spec.Access
+	return len(access.Roles) > 0 || len(access.Claims) > 0 || len(access.Include) > 0
+}
+
+// AccessPolicies builds the policies listed in spec.access.policies. Policies
+// without a precedence follow the generated allow policy in list order. The
+// service token policy, if any, comes last.
+func AccessPolicies(app *accessv1alpha1.SecuredApplication, refs Refs) []cfclient.AccessPolicy {
+	offset := 0
+	if HasAllowPolicy(app) {
+		offset = 1
+	}
+	last := offset
+	policies := make([]cfclient.AccessPolicy, 0, len(app.Spec.Access.Policies)+1)
+	for i, policy := range app.Spec.Access.Policies {
+		precedence := policy.Precedence
+		if precedence == 0 {
+			precedence = offset + i + 1
+		}
+		last = max(last, precedence)
+		policies = append(policies, cfclient.AccessPolicy{
+			Name:            policy.Name,
+			Decision:        policy.Decision,
+			Precedence:      precedence,
+			Include:         Rules(policy.Include, refs),
+			Require:         Rules(policy.Require, refs),
+			Exclude:         Rules(policy.Exclude, refs),
+			SessionDuration: policy.SessionDuration,
+		})
+	}
+
+	if tokens := app.Spec.Access.ServiceTokens; len(tokens) > 0 {
+		include := make([]cfclient.Rule, 0, len(tokens))
+		for _, token := range tokens {
+			include = append(include, cfclient.Rule{ServiceToken: &cfclient.ServiceTokenRule{TokenID: refs.serviceTokenID(token.Name)}})
+		}
+		policies = append(policies, cfclient.AccessPolicy{
+			Name:       ServiceTokenPolicyName,
+			Decision:   "non_identity",
+			Precedence: last + 1,
+			Include:    include,
+		})
+	}
+	return policies
+}
+
+// ReusablePolicy builds the account-level policy of an AccessPolicy. The name
+// defaults to the object's name.
+func ReusablePolicy(policy *accessv1alpha1.AccessPolicy, refs Refs) cfclient.AccessPolicy {
+	name := policy.Spec.Name
+	if name == "" {
+		name = policy.Name
+	}
+	return cfclient.AccessPolicy{
+		Name:            name,
+		Decision:        policy.Spec.Decision,
+		Include:         Rules(policy.Spec.Include, refs),
+		Require:         Rules(policy.Spec.Require, refs),
+		Exclude:         Rules(policy.Spec.Exclude, refs),
+		SessionDuration: policy.Spec.SessionDuration,
+	}
+}
+
+// Group builds the Cloudflare Access group of an AccessGroup. The name
+// defaults to the object's name.
+func Group(group *accessv1alpha1.AccessGroup, refs Refs) cfclient.AccessGroup {
+	name := group.Spec.Name
+	if name == "" {
+		name = group.Name
+	}
+	return cfclient.AccessGroup{
+		Name:    name,
+		Include: Rules(group.Spec.Include, refs),
+		Require: Rules(group.Spec.Require, refs),
+		Exclude: Rules(group.Spec.Exclude, refs),
+	}
+}
+
+// Rules converts spec rules to Cloudflare rules, in order.
+func Rules(rules []accessv1alpha1.AccessRule, refs Refs) []cfclient.Rule {
+	var out []cfclient.Rule
+	for _, rule := range rules {
+		out = append(out, Rule(rule, refs))
+	}
+	return out
+}
+
+// Rule converts one spec rule to a Cloudflare rule. Role and claim rules are
+// checked against the Zitadel identity provider in refs.
+func Rule(rule accessv1alpha1.AccessRule, refs Refs) cfclient.Rule {
+	switch {
+	case rule.Role != "":
+		return roleRule(refs.IdPID, rule.Role)
+	case rule.Claim != nil:
+		return claimRule(refs.IdPID, *rule.Claim)
+	case rule.Email != "":
+		return cfclient.Rule{Email: &cfclient.EmailRule{Email: rule.Email}}
+	case rule.EmailDomain != "":
+		return cfclient.Rule{EmailDomain: &cfclient.EmailDomainRule{Domain: rule.EmailDomain}}
+	case rule.IP != "":
+		return cfclient.Rule{IP: &cfclient.IPRule{IP: rule.IP}}
+	case rule.Country != "":
+		return cfclient.Rule{Geo: &cfclient.GeoRule{CountryCode: rule.Country}}
+	case rule.GroupID != "":
+		return cfclient.Rule{Group: &cfclient.GroupRule{ID: rule.GroupID}}
+	case rule.GroupRef != "":
+		return cfclient.Rule{Group: &cfclient.GroupRule{ID: refs.groupID(rule.GroupRef)}}
+	case rule.ServiceTokenID != "":
+		return cfclient.Rule{ServiceToken: &cfclient.ServiceTokenRule{TokenID: rule.ServiceTokenID}}
+	case rule.AnyValidServiceToken:
+		return cfclient.Rule{AnyValidServiceToken: &struct{}{}}
+	case rule.Certificate:
+		return cfclient.Rule{Certificate: &struct{}{}}
+	case rule.CommonName != "":
+		return cfclient.Rule{CommonName: &cfclient.CommonNameRule{CommonName: rule.CommonName}}
+	case rule.DevicePosture != "":
+		return cfclient.Rule{DevicePosture: &cfclient.DevicePostureRule{IntegrationUID: rule.DevicePosture}}
+	}
+	return cfclient.Rule{}
+}
+
+func roleRule(idpID, role string) cfclient.Rule {
+	return claimRule(idpID, accessv1alpha1.ClaimCheck{Name: RoleClaimName, Value: role})
+}
+
+func claimRule(idpID string, claim accessv1alpha1.ClaimCheck) cfclient.Rule {
+	return cfclient.Rule{OIDC: &cfclient.OIDCClaimRule{
+		IdentityProviderID: idpID,
+		ClaimName:          claim.Name,
+		ClaimValue:         claim.Value,
+	}}
+}
+
+// DefaultServiceTokenDuration is Cloudflare's default service token lifetime.
+const DefaultServiceTokenDuration = 365 * 24 * time.Hour
+
+// ServiceToken returns the Cloudflare name and lifetime of a service token
+// in spec.access.serviceTokens.
+func ServiceToken(app *accessv1alpha1.SecuredApplication, token *accessv1alpha1.ServiceTokenConfig) (string, time.Duration) {
+	duration := DefaultServiceTokenDuration
+	if token.Duration != nil && token.Duration.Duration > 0 {
+		duration = token.Duration.Duration
+	}
+	return app.Name + "-" + token.Name, duration
+}
+
+// BypassApp is an Access Application that lets unauthenticated requests
+// through to one path on every host.
+type BypassApp struct {
+	Path    string
+	Name    string
+	Domains []string
+}
+
+// BypassApps returns one bypass app per bypass path, in spec order.
+func BypassApps(app *accessv1alpha1.SecuredApplication) []BypassApp {
+	apps := make([]BypassApp, 0, len(app.Spec.Access.BypassPaths))
+	for _, path := range app.Spec.Access.BypassPaths {
+		var domains []string
+		for _, host := range Hosts(app) {
+			domains = append(domains, host+path)
+		}
+		apps = append(apps, BypassApp{
+			Path:    path,
+			Name:    fmt.Sprintf("%s-bypass-%s", app.Name, path),
+			Domains: domains,
+		})
+	}
+	return apps
+}
+
+// PathApp is the Access Application and allow policy protecting one path of
+// spec.access.pathRules on every host.
+type PathApp struct {
+	Path string
+	App  cfclient.DesiredAccessApp
+}
+
+// PathApps returns one path app per spec.access.pathRules entry, in spec
+// order.
+func PathApps(app *accessv1alpha1.SecuredApplication, refs Refs, sessionDuration string) []PathApp {
+	apps := make([]PathApp, 0, len(app.Spec.Access.PathRules))
+	for _, rule := range app.Spec.Access.PathRules {
+		var domains []string
+		for _, host := range Hosts(app) {
+			domains = append(domains, host+rule.Path)
+		}
+		apps = append(apps, PathApp{
+			Path: rule.Path,
+			App: cfclient.DesiredAccessApp{
+				Name:            fmt.Sprintf("%s-path-%s", app.Name, rule.Path),
+				Domains:         domains,
+				SessionDuration: sessionDuration,
+				Policy:          policyRules(rule.Roles, rule.Claims, rule.Match, rule.Include, rule.Require, rule.Exclude, refs),
+			},
+		})
+	}
+	return apps
+}
@@ -0,0 +1,191 @@
+package builder
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+func ruleStrings(rules []cfclient.Rule) []string {
+	out := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, rule.String())
+	}
+	return out
+}
+
+func TestAccessAppPolicy(t *testing.T) {
+	finance := accessv1alpha1.ClaimCheck{Name: "custom:department", Value: "finance"}
+	oncall := accessv1alpha1.ClaimCheck{Name: "custom:team", Value: "oncall"}
+
+	tests := []struct {
+		name    string
+		access  accessv1alpha1.Access
+		include []string
+		require []string
+		exclude []string
+	}{
+		{
+			name:    "any, roles only",
+			access:  accessv1alpha1.Access{Roles: []string{"admin", "viewer"}},
+			include: []string{"oidc custom:roles=admin", "oidc custom:roles=viewer"},
+		},
+		{
+			name:    "any, claims only",
+			access:  accessv1alpha1.Access{Claims: []accessv1alpha1.ClaimCheck{finance, oncall}},
+			include: []string{"oidc custom:department=finance", "oidc custom:team=oncall"},
+		},
+		{
+			name:    "any, roles and claims",
+			access:  accessv1alpha1.Access{Roles: []string{"admin"}, Claims: []accessv1alpha1.ClaimCheck{finance}},
+			include: []string{"oidc custom:roles=admin", "oidc custom:department=finance"},
+		},
+		{
+			name:    "explicit any, roles and claims",
+			access:  accessv1alpha1.Access{Match: "any", Roles: []string{"admin"}, Claims: []accessv1alpha1.ClaimCheck{finance}},
+			include: []string{"oidc custom:roles=admin", "oidc custom:department=finance"},
+		},
+		{
+			name: "any, extra rules",
+			access: accessv1alpha1.Access{
+				Roles:   []string{"admin"},
+				Claims:  []accessv1alpha1.ClaimCheck{finance},
+				Include: []accessv1alpha1.AccessRule{{Email: "alice@example.com"}},
+				Require: []accessv1alpha1.AccessRule{{Country: "DE"}},
+				Exclude: []accessv1alpha1.AccessRule{{IP: "203.0.113.0/24"}},
+			},
+			include: []string{"oidc custom:roles=admin", "oidc custom:department=finance", "email alice@example.com"},
+			require: []string{"geo DE"},
+			exclude: []string{"ip 203.0.113.0/24"},
+		},
+		{
+			name:    "all, roles only",
+			access:  accessv1alpha1.Access{Match: "all", Roles: []string{"admin", "viewer"}},
+			include: []string{"oidc custom:roles=admin", "oidc custom:roles=viewer"},
+		},
+		{
+			name:    "all, claims only moves the first claim into include",
+			access:  accessv1alpha1.Access{Match: "all", Claims: []accessv1alpha1.ClaimCheck{finance, oncall}},
+			include: []string{"oidc custom:department=finance"},
+			require: []string{"oidc custom:department=finance", "oidc custom:team=oncall"},
+		},
+		{
+			name:    "all, roles and claims",
+			access:  accessv1alpha1.Access{Match: "all", Roles: []string{"admin"}, Claims: []accessv1alpha1.ClaimCheck{finance, oncall}},
+			include: []string{"oidc custom:roles=admin"},
+			require: []string{"oidc custom:department=finance", "oidc custom:team=oncall"},
+		},
+		{
+			name: "all, claims and include rules",
+			access: accessv1alpha1.Access{
+				Match:   "all",
+				Claims:  []accessv1alpha1.ClaimCheck{finance},
+				Include: []accessv1alpha1.AccessRule{{EmailDomain: "example.com"}},
+			},
+			include: []string{"email_domain example.com"},
+			require: []string{"oidc custom:department=finance"},
+		},
+		{
+			name: "all, extra rules",
+			access: accessv1alpha1.Access{
+				Match:   "all",
+				Roles:   []string{"admin"},
+				Claims:  []accessv1alpha1.ClaimCheck{finance},
+				Include: []accessv1alpha1.AccessRule{{Email: "alice@example.com"}},
+				Require: []accessv1alpha1.AccessRule{{Country: "DE"}},
+				Exclude: []accessv1alpha1.AccessRule{{IP: "198.51.100.0/24"}},
+			},
+			include: []string{"oidc custom:roles=admin", "email alice@example.com"},
+			require: []string{"oidc custom:department=finance", "geo DE"},
+			exclude: []string{"ip 198.51.100.0/24"},
+		},
+		{
+			name: "group references",
+			access: accessv1alpha1.Access{
+				Roles:   []string{"admin"},
+				Exclude: []accessv1alpha1.AccessRule{{GroupRef: "contractors"}, {GroupRef: "interns"}},
+			},
+			include: []string{"oidc custom:roles=admin"},
+			exclude: []string{"group grp-contractors", "group <AccessGroup interns>"},
+		},
+	}
+
+	refs := Refs{IdPID: "idp1", GroupIDs: map[string]string{"contractors": "grp-contractors"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &accessv1alpha1.SecuredApplication{}
+			app.Name = "wiki"
+			app.Spec.Host = "wiki.example.com"
+			app.Spec.Access = tt.access
+
+			policy := AccessApp(app, refs, "24h").Policy
+			if got := ruleStrings(policy.Include); !slices.Equal(got, tt.include) {
+				t.Errorf("include = %q, want %q", got, tt.include)
+			}
+			if got := ruleStrings(policy.Require); !slices.Equal(got, tt.require) {
+				t.Errorf("require = %q, want %q", got, tt.require)
+			}
+			if got := ruleStrings(policy.Exclude); !slices.Equal(got, tt.exclude) {
+				t.Errorf("exclude = %q, want %q", got, tt.exclude)
+			}
+		})
+	}
+}
+
+func TestAllowPolicyBodyMatchAllClaimsOnly(t *testing.T) {
+	app := &accessv1alpha1.SecuredApplication{}
+	app.Name = "wiki"
+	app.Spec.Host = "wiki.example.com"
+	app.Spec.Access = accessv1alpha1.Access{
+		Match:  "all",
+		Claims: []accessv1alpha1.ClaimCheck{{Name: "custom:department", Value: "finance"}},
+	}
+
+	body, err := json.Marshal(cfclient.AllowPolicyBody(AccessApp(app, Refs{IdPID: "idp1"}, "24h").Policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"name":"Allow Zitadel roles","decision":"allow","precedence":1,` +
+		`"include":[{"oidc":{"identity_provider_id":"idp1","claim_name":"custom:department","claim_value":"finance"}}],` +
+		`"require":[{"oidc":{"identity_provider_id":"idp1","claim_name":"custom:department","claim_value":"finance"}}]}`
+	if string(body) != want {
+		t.Errorf("policy body =\n%s\nwant\n%s", body, want)
+	}
+}
+
+func TestPathAppsUseTheSameRuleModel(t *testing.T) {
+	app := &accessv1alpha1.SecuredApplication{}
+	app.Name = "wiki"
+	app.Spec.Host = "wiki.example.com"
+	app.Spec.Hosts = []string{"wiki.example.org"}
+	app.Spec.Access = accessv1alpha1.Access{
+		Roles: []string{"viewer"},
+		PathRules: []accessv1alpha1.PathRule{{
+			Path:   "/admin",
+			Match:  "all",
+			Roles:  []string{"admin"},
+			Claims: []accessv1alpha1.ClaimCheck{{Name: "custom:department", Value: "it"}},
+		}},
+	}
+
+	apps := PathApps(app, Refs{IdPID: "idp1"}, "8h")
+	if len(apps) != 1 {
+		t.Fatalf("got %d path apps, want 1", len(apps))
+	}
+	got := apps[0]
+	if got.Path != "/admin" || got.App.Name != "wiki-path-/admin" || got.App.SessionDuration != "8h" {
+		t.Errorf("path app = %+v", got)
+	}
+	if want := []string{"wiki.example.com/admin", "wiki.example.org/admin"}; !slices.Equal(got.App.Domains, want) {
+		t.Errorf("domains = %q, want %q", got.App.Domains, want)
+	}
+	if include := ruleStrings(got.App.Policy.Include); !slices.Equal(include, []string{"oidc custom:roles=admin"}) {
+		t.Errorf("include = %q", include)
+	}
+	if require := ruleStrings(got.App.Policy.Require); !slices.Equal(require, []string{"oidc custom:department=it"}) {
+		t.Errorf("require = %q", require)
+	}
+}
@@ -0,0 +1,19 @@
+package builder
+
+import (
+	"slices"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+)
+
+// Hosts returns spec.host followed by spec.hosts, without duplicates. The
+// first host is the primary one, e.g. the Access Application's domain.
+func Hosts(app *accessv1alpha1.SecuredApplication) []string {
+	var hosts []string
+	for _, host := range append([]string{app.Spec.Host}, app.Spec.Hosts...) {
+		if host != "" && !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,95 @@
+package builder
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+)
+
+// HTTPRoutes are handled as unstructured objects so the operator does not
+// depend on the Gateway API module and runs on clusters without its CRDs.
+var HTTPRouteGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1",
+	Kind:    "HTTPRoute",
+}
+
+// TunnelHTTPRoute builds the HTTPRoute for every host. app must have
+// routing.gateway set.
+func TunnelHTTPRoute(app *accessv1alpha1.SecuredApplication) *unstructured.Unstructured {
+	return httpRoute(app, app.Name, Hosts(app), *app.Spec.Routing.Gateway, tunnelRoutes(app))
+}
+
+// OIDCHTTPRoute builds the HTTPRoute for the native OIDC host, which uses
+// routing.oidcGateway if set. app must have nativeOIDC.ingress and
+// routing.gateway set.
+func OIDCHTTPRoute(app *accessv1alpha1.SecuredApplication) *unstructured.Unstructured {
+	oidcIngress := app.Spec.NativeOIDC.Ingress
+	gateway := app.Spec.Routing.Gateway
+	if app.Spec.Routing.OIDCGateway != nil {
+		gateway = app.Spec.Routing.OIDCGateway
+	}
+	return httpRoute(app, app.Name+"-oidc", []string{oidcIngress.Host}, *gateway, routes(app, oidcIngress.Path, oidcIngress.PathType))
+}
+
+func httpRoute(app *accessv1alpha1.SecuredApplication, name string, hosts []string, gateway accessv1alpha1.GatewayRef, routes []accessv1alpha1.Route) *unstructured.Unstructured {
+	parentRef := map[string]any{
+		"group": HTTPRouteGVK.Group,
+		"kind":  "Gateway",
+		"name":  gateway.Name,
+	}
+	if gateway.Namespace != "" {
+		parentRef["namespace"] = gateway.Namespace
+	}
+	if gateway.SectionName != "" {
+		parentRef["sectionName"] = gateway.SectionName
+	}
+
+	rules := make([]any, 0, len(routes))
+	for _, rt := range routes {
+		rules = append(rules, map[string]any{
+			"matches": []any{
+				map[string]any{
+					"path": map[string]any{
+						"type":  httpRoutePathType(rt.PathType),
+						"value": rt.Path,
+					},
+				},
+			},
+			"backendRefs": []any{
+				map[string]any{
+					"group":  "",
+					"kind":   "Service",
+					"name":   rt.Backend.ServiceName,
+					"port":   int64(rt.Backend.ServicePort),
+					"weight": int64(1),
+				},
+			},
+		})
+	}
+
+	hostnames := make([]any, 0, len(hosts))
+	for _, host := range hosts {
+		hostnames = append(hostnames, host)
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	route.SetName(name)
+	route.SetNamespace(app.Namespace)
+	route.Object["spec"] = map[string]any{
+		"parentRefs": []any{parentRef},
+		"hostnames":  hostnames,
+		"rules":      rules,
+	}
+	return route
+}
+
+// httpRoutePathType maps an Ingress pathType to the Gateway API path match type.
+func httpRoutePathType(pathType string) string {
+	if pathType == "Exact" {
+		return "Exact"
+	}
+	return "PathPrefix"
+}
@@ -0,0 +1,96 @@
+package builder
+
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+)
+
+const (
+	// DefaultIngressClassName is the class of the tunnel Ingress unless
+	// spec.ingress.className overrides it.
+	DefaultIngressClassName = "cloudflare-tunnel"
+
+	CFBackendProtocolAnnotation = "cloudflare-tunnel-ingress-controller.strrl.dev/backend-protocol"
+)
+
+// Ingress builds the tunnel Ingress with a rule for each host. It has no
+// owner reference.
+func Ingress(app *accessv1alpha1.SecuredApplication) *networkingv1.Ingress {
+	className := DefaultIngressClassName
+	if app.Spec.Ingress != nil && app.Spec.Ingress.ClassName != "" {
+		className = app.Spec.Ingress.ClassName
+	}
+
+	annotations := make(map[string]string)
+	if app.Spec.Ingress != nil {
+		for k, v := range app.Spec.Ingress.Annotations {
+			annotations[k] = v
+		}
+	}
+	if protocol := BackendProtocol(app); protocol != "" {
+		annotations[CFBackendProtocolAnnotation] = protocol
+	}
+	return ingress(app, app.Name, className, annotations, Hosts(app), tunnelRoutes(app))
+}
+
+// OIDCIngress builds the Ingress for the native OIDC host, which bypasses
+// Cloudflare Access. app must have nativeOIDC.ingress set.
+func OIDCIngress(app *accessv1alpha1.SecuredApplication) *networkingv1.Ingress {
+	oidcIngress := app.Spec.NativeOIDC.Ingress
+
+	annotations := make(map[string]string)
+	for k, v := range oidcIngress.Annotations {
+		annotations[k] = v
+	}
+	return ingress(app, app.Name+"-oidc", oidcIngress.ClassName, annotations, []string{oidcIngress.Host}, routes(app, oidcIngress.Path, oidcIngress.PathType))
+}
+
+func ingress(app *accessv1alpha1.SecuredApplication, name, className string, annotations map[string]string, hosts []string, routes []accessv1alpha1.Route) *networkingv1.Ingress {
+	paths := make([]networkingv1.HTTPIngressPath, 0, len(routes))
+	for _, route := range routes {
+		pt := networkingv1.PathTypePrefix
+		if route.PathType != "" {
+			pt = networkingv1.PathType(route.PathType)
+		}
+		paths = append(paths, networkingv1.HTTPIngressPath{
+			Path:     route.Path,
+			PathType: &pt,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: route.Backend.ServiceName,
+					Port: networkingv1.ServiceBackendPort{
+						Number: route.Backend.ServicePort,
+					},
+				},
+			},
+		})
+	}
+
+	rules := make([]networkingv1.IngressRule, 0, len(hosts))
+	for _, host := range hosts {
+		rules = append(rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+			},
+		})
+	}
+
+	return &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+			Kind:       "Ingress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   app.Namespace,
+			Annotations: annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: &className,
+			Rules:            rules,
+		},
+	}
+}
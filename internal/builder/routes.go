@@ -0,0 +1,40 @@
+package builder
+
+import (
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+)
+
+// routes returns spec.routes or, without them, a single route from path to
+// spec.backend.
+func routes(app *accessv1alpha1.SecuredApplication, path, pathType string) []accessv1alpha1.Route {
+	if len(app.Spec.Routes) > 0 {
+		return app.Spec.Routes
+	}
+	if path == "" {
+		path = "/"
+	}
+	return []accessv1alpha1.Route{{Path: path, PathType: pathType, Backend: *app.Spec.Backend}}
+}
+
+// tunnelRoutes returns the routes of the tunnel Ingress, HTTPRoute or tunnel
+// rules.
+func tunnelRoutes(app *accessv1alpha1.SecuredApplication) []accessv1alpha1.Route {
+	var path, pathType string
+	if app.Spec.Ingress != nil {
+		path, pathType = app.Spec.Ingress.Path, app.Spec.Ingress.PathType
+	}
+	return routes(app, path, pathType)
+}
+
+// BackendProtocol returns the protocol shared by every route's backend, or ""
+// if it is not set or differs between routes.
+func BackendProtocol(app *accessv1alpha1.SecuredApplication) string {
+	all := routes(app, "", "")
+	protocol := all[0].Backend.Protocol
+	for _, route := range all[1:] {
+		if route.Backend.Protocol != protocol {
+			return ""
+		}
+	}
+	return protocol
+}
@@ -0,0 +1,51 @@
+package builder
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// TunnelIngressRules builds the Cloudflare Tunnel ingress rules for each
+// host in Tunnel routing mode, grouped by host. Route paths are turned into
+// the anchored regexes cloudflared matches against. cloudflared uses the
+// first matching rule, so longer paths come first.
+func TunnelIngressRules(app *accessv1alpha1.SecuredApplication) []cfclient.TunnelIngressRule {
+	var hostRules []cfclient.TunnelIngressRule
+	for _, route := range tunnelRoutes(app) {
+		protocol := "http"
+		if route.Backend.Protocol != "" {
+			protocol = route.Backend.Protocol
+		}
+		hostRules = append(hostRules, cfclient.TunnelIngressRule{
+			Path:    tunnelPath(route.Path, route.PathType),
+			Service: fmt.Sprintf("%s://%s.%s:%d", protocol, route.Backend.ServiceName, app.Namespace, route.Backend.ServicePort),
+		})
+	}
+	slices.SortStableFunc(hostRules, func(a, b cfclient.TunnelIngressRule) int {
+		return cmp.Compare(len(b.Path), len(a.Path))
+	})
+
+	var rules []cfclient.TunnelIngressRule
+	for _, host := range Hosts(app) {
+		for _, rule := range hostRules {
+			rule.Hostname = host
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func tunnelPath(path, pathType string) string {
+	if path == "" || (path == "/" && pathType != "Exact") {
+		return ""
+	}
+	if pathType == "Exact" {
+		return "^" + regexp.QuoteMeta(path) + "$"
+	}
+	return "^" + regexp.QuoteMeta(path)
+}
@@ -0,0 +1,70 @@
+// Package builder turns a SecuredApplication spec into the Zitadel app
+// configuration, Cloudflare Access payloads and Kubernetes objects the
+// operator applies. It does no I/O, so it is shared by the controller and the
+// render command.
+package builder
+
+import (
+	"fmt"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/zitadel"
+)
+
+// ZitadelAppConfig builds the Zitadel OIDC app configuration for app.
+func ZitadelAppConfig(app *accessv1alpha1.SecuredApplication) zitadel.AppConfig {
+	// Construct a redirect URI from each host + path.
+	redirectHosts := Hosts(app)
+	redirectPath := "/callback"
+
+	config := zitadel.AppConfig{
+		Name:            app.Name,
+		ResponseTypes:   []string{"OIDC_RESPONSE_TYPE_CODE"},
+		GrantTypes:      []string{"OIDC_GRANT_TYPE_AUTHORIZATION_CODE"},
+		AppType:         "OIDC_APP_TYPE_WEB",
+		AuthMethodType:  "OIDC_AUTH_METHOD_TYPE_BASIC",
+		AccessTokenType: "OIDC_TOKEN_TYPE_BEARER",
+	}
+
+	if app.Spec.NativeOIDC != nil {
+		if app.Spec.NativeOIDC.Ingress != nil {
+			redirectHosts = []string{app.Spec.NativeOIDC.Ingress.Host}
+		}
+		if app.Spec.NativeOIDC.RedirectPath != "" {
+			redirectPath = app.Spec.NativeOIDC.RedirectPath
+		}
+		if app.Spec.NativeOIDC.PostLogoutRedirectPath != "" {
+			config.PostLogoutRedirectURIs = redirectURIs(redirectHosts, app.Spec.NativeOIDC.PostLogoutRedirectPath)
+		}
+		if len(app.Spec.NativeOIDC.ResponseTypes) > 0 {
+			config.ResponseTypes = app.Spec.NativeOIDC.ResponseTypes
+		}
+		if len(app.Spec.NativeOIDC.GrantTypes) > 0 {
+			config.GrantTypes = app.Spec.NativeOIDC.GrantTypes
+		}
+		if app.Spec.NativeOIDC.AppType != "" {
+			config.AppType = app.Spec.NativeOIDC.AppType
+		}
+		if app.Spec.NativeOIDC.AuthMethodType != "" {
+			config.AuthMethodType = app.Spec.NativeOIDC.AuthMethodType
+		}
+		if app.Spec.NativeOIDC.AccessTokenType != "" {
+			config.AccessTokenType = app.Spec.NativeOIDC.AccessTokenType
+		}
+		config.DevMode = app.Spec.NativeOIDC.DevMode
+		config.IDTokenRoleAssertion = app.Spec.NativeOIDC.IDTokenRoleAssertion
+		config.IDTokenUserinfoAssertion = app.Spec.NativeOIDC.IDTokenUserinfoAssertion
+		config.AccessTokenRoleAssertion = app.Spec.NativeOIDC.AccessTokenRoleAssertion
+	}
+
+	config.RedirectURIs = redirectURIs(redirectHosts, redirectPath)
+	return config
+}
+
+func redirectURIs(hosts []string, path string) []string {
+	uris := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		uris = append(uris, fmt.Sprintf("https://%s%s", host, path))
+	}
+	return uris
+}
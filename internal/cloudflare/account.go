@@ -0,0 +1,93 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Reusable policies and Access groups are account-level objects that the
+// operator manages by ID. The helpers below hold what their calls have in
+// common; kind names the object in errors, e.g. "access group".
+
+// getAccountObject fetches the object at path, or returns nil if it does not
+// exist.
+func getAccountObject[T any](ctx context.Context, c *httpClient, path, kind string) (*T, error) {
+	respBody, err := c.do(ctx, http.MethodGet, c.accountPath(path), nil)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get %s: %w", kind, err)
+	}
+
+	var result struct {
+		Result T `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", kind, err)
+	}
+	return &result.Result, nil
+}
+
+// detectAccountObjectDrift fetches the object id in collection and compares
+// it against the desired state with diff. missing is set if there is no ID
+// yet or the object was deleted.
+func detectAccountObjectDrift[T any](ctx context.Context, c *httpClient, collection, id, kind string, diff func(*T) []string) (drift *Drift, missing bool, err error) {
+	drift = &Drift{}
+	if id == "" {
+		return drift, true, nil
+	}
+	current, err := getAccountObject[T](ctx, c, collection+"/"+id, kind)
+	if err != nil {
+		return nil, false, err
+	}
+	if current == nil {
+		drift.Changes = append(drift.Changes, fmt.Sprintf("%s %s was deleted", kind, id))
+		return drift, true, nil
+	}
+	drift.Changes = diff(current)
+	return drift, false, nil
+}
+
+// upsertAccountObject updates the object existingID in collection, or
+// creates it if there is no ID or it was deleted outside the operator. It
+// returns the object's ID.
+func (c *httpClient) upsertAccountObject(ctx context.Context, collection, existingID, kind string, body any) (string, error) {
+	if existingID != "" {
+		_, err := c.do(ctx, http.MethodPut, c.accountPath(collection+"/"+existingID), body)
+		if err == nil {
+			return existingID, nil
+		}
+		if !IsNotFound(err) {
+			return "", fmt.Errorf("update %s: %w", kind, err)
+		}
+		// Deleted outside the operator; create it again below.
+	}
+
+	respBody, err := c.do(ctx, http.MethodPost, c.accountPath(collection), body)
+	if err != nil {
+		return "", fmt.Errorf("create %s: %w", kind, err)
+	}
+
+	var result struct {
+		Result struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("unmarshal %s response: %w", kind, err)
+	}
+	return result.Result.ID, nil
+}
+
+// deleteAccountObject deletes the object id in collection. Deleting an
+// object that no longer exists is not an error.
+func (c *httpClient) deleteAccountObject(ctx context.Context, collection, id, kind string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.accountPath(collection+"/"+id), nil)
+	if err != nil && !IsNotFound(err) {
+		return fmt.Errorf("delete %s: %w", kind, err)
+	}
+	return nil
+}
@@ -7,55 +7,204 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/twiechert/cf-zitadel-access-operator/internal/metrics"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/retry"
 )
 
-const baseURL = "https://api.cloudflare.com/client/v4"
+const (
+	baseURL = "https://api.cloudflare.com/client/v4"
+
+	// listPageSize is the per_page value used when walking list endpoints.
+	listPageSize = 100
+)
 
 // AccessApp represents a Cloudflare Access Application.
 type AccessApp struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Domain          string `json:"domain,omitempty"`
+	SessionDuration string `json:"session_duration,omitempty"`
+
+	// Destinations lists every hostname (and path) the application
+	// protects. Domain is the first of them.
+	Destinations []Destination `json:"destinations,omitempty"`
+
+	// Policies lists every policy attached to the application.
+	Policies []AppPolicyLink `json:"policies,omitempty"`
 }
 
-// AccessPolicy represents a Cloudflare Access Policy.
-type AccessPolicy struct {
-	ID string `json:"id"`
+// Destination is a hostname, optionally with a path, protected by an Access
+// Application.
+type Destination struct {
+	Type string `json:"type"`
+	URI  string `json:"uri"`
 }
 
-// OIDCClaimRule defines an inline OIDC claim check for an Access Policy.
-type OIDCClaimRule struct {
-	IdentityProviderID string `json:"identity_provider_id"`
-	ClaimName          string `json:"claim_name"`
-	ClaimValue         string `json:"claim_value"`
+// AppPolicyLink attaches a policy to an Access Application.
+type AppPolicyLink struct {
+	ID         string `json:"id"`
+	Precedence int    `json:"precedence,omitempty"`
+
+	// Reusable is set by the API for account-level policies.
+	Reusable bool `json:"reusable,omitempty"`
+}
+
+// AccessPolicy represents a Cloudflare Access Policy. It is also the request
+// body when creating or updating one.
+type AccessPolicy struct {
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Decision        string `json:"decision,omitempty"`
+	Precedence      int    `json:"precedence,omitempty"`
+	Include         []Rule `json:"include"`
+	Require         []Rule `json:"require,omitempty"`
+	Exclude         []Rule `json:"exclude,omitempty"`
+	SessionDuration string `json:"session_duration,omitempty"`
 }
 
-// Client talks to the Cloudflare Access API.
+// Client talks to the Cloudflare Access API. Failed API calls return errors
+// wrapping *APIError; rate limits and server errors are retried with backoff.
 type Client interface {
 	// FindAccessAppByDomain returns the Access Application for the given domain, or nil.
 	FindAccessAppByDomain(ctx context.Context, domain string) (*AccessApp, error)
 
-	// CreateAccessApp creates a self-hosted Access Application.
-	CreateAccessApp(ctx context.Context, name, domain, sessionDuration string) (*AccessApp, error)
+	// GetAccessApp returns the Access Application with the given ID, or nil if it no longer exists.
+	GetAccessApp(ctx context.Context, appID string) (*AccessApp, error)
+
+	// GetAccessPolicy returns the policy on an Access Application, or nil if it no longer exists.
+	GetAccessPolicy(ctx context.Context, appID, policyID string) (*AccessPolicy, error)
+
+	// DetectDrift fetches the Access Application and its allow policy and
+	// compares them against the desired state.
+	DetectDrift(ctx context.Context, appID, policyID string, desired DesiredAccessApp) (*Drift, error)
 
-	// UpdateAccessApp updates an existing Access Application.
-	UpdateAccessApp(ctx context.Context, appID, name, domain, sessionDuration string) error
+	// DetectPolicyDrift fetches a policy on an Access Application and
+	// compares it against the desired policy.
+	DetectPolicyDrift(ctx context.Context, appID, policyID string, desired AccessPolicy) (*Drift, error)
 
-	// DeleteAccessApp deletes an Access Application.
+	// CreateAccessApp creates a self-hosted Access Application protecting
+	// domains. The first domain is the application's primary domain.
+	CreateAccessApp(ctx context.Context, name string, domains []string, sessionDuration string) (*AccessApp, error)
+
+	// UpdateAccessApp updates an existing Access Application. If policies is
+	// not nil, it replaces the list of attached policies, which must then
+	// include the application's own policies too.
+	UpdateAccessApp(ctx context.Context, appID, name string, domains []string, sessionDuration string, policies []AppPolicyLink) error
+
+	// DeleteAccessApp deletes an Access Application. Deleting an app that no
+	// longer exists is not an error.
 	DeleteAccessApp(ctx context.Context, appID string) error
 
-	// UpsertAccessPolicy creates or updates the allow policy on an Access Application
-	// with inline OIDC claim rules (one per role).
-	UpsertAccessPolicy(ctx context.Context, appID string, existingPolicyID string, rules []OIDCClaimRule) (*AccessPolicy, error)
+	// UpsertAccessPolicy creates or updates a policy on an Access Application.
+	// A policy that no longer exists is created again.
+	UpsertAccessPolicy(ctx context.Context, appID string, existingPolicyID string, policy AccessPolicy) (*AccessPolicy, error)
+
+	// DeleteAccessPolicy deletes a policy from an Access Application.
+	// Deleting a policy that no longer exists is not an error.
+	DeleteAccessPolicy(ctx context.Context, appID, policyID string) error
+
+	// DetectReusablePolicyDrift fetches an account-level policy and compares
+	// it against the desired policy.
+	DetectReusablePolicyDrift(ctx context.Context, policyID string, desired AccessPolicy) (*Drift, error)
+
+	// UpsertReusablePolicy creates or updates an account-level policy that
+	// can be attached to several Access Applications, and returns its ID. A
+	// policy that no longer exists is created again.
+	UpsertReusablePolicy(ctx context.Context, existingPolicyID string, policy AccessPolicy) (string, error)
+
+	// DeleteReusablePolicy deletes an account-level policy. Deleting a policy
+	// that no longer exists is not an error.
+	DeleteReusablePolicy(ctx context.Context, policyID string) error
+
+	// DetectGroupDrift fetches an Access group and compares it against the
+	// desired group.
+	DetectGroupDrift(ctx context.Context, groupID string, desired AccessGroup) (*Drift, error)
+
+	// UpsertAccessGroup creates or updates an Access group and returns its
+	// ID. A group that no longer exists is created again.
+	UpsertAccessGroup(ctx context.Context, existingGroupID string, group AccessGroup) (string, error)
+
+	// DeleteAccessGroup deletes an Access group. Deleting a group that no
+	// longer exists is not an error.
+	DeleteAccessGroup(ctx context.Context, groupID string) error
+
+	// GetServiceToken returns the service token with the given ID, or nil if
+	// it no longer exists.
+	GetServiceToken(ctx context.Context, tokenID string) (*ServiceToken, error)
+
+	// CreateServiceToken creates a service token valid for duration (e.g.
+	// "8760h"). The result carries the client secret.
+	CreateServiceToken(ctx context.Context, name, duration string) (*ServiceToken, error)
+
+	// UpdateServiceToken changes the name and duration of a service token.
+	UpdateServiceToken(ctx context.Context, tokenID, name, duration string) error
+
+	// RotateServiceToken generates a new client secret for a service token
+	// and revokes the old one. The result carries the new client secret.
+	RotateServiceToken(ctx context.Context, tokenID string) (*ServiceToken, error)
+
+	// RefreshServiceToken extends the expiry of a service token by its duration.
+	RefreshServiceToken(ctx context.Context, tokenID string) (*ServiceToken, error)
+
+	// DeleteServiceToken deletes a service token. Deleting a token that no
+	// longer exists is not an error.
+	DeleteServiceToken(ctx context.Context, tokenID string) error
+
+	// FindTunnelByName returns the Cloudflare Tunnel with the given name, or nil.
+	FindTunnelByName(ctx context.Context, name string) (*Tunnel, error)
+
+	// UpsertTunnelRoutes sets the rules for hostname in the tunnel's
+	// configuration, in order, replacing any existing ones and keeping the
+	// catch-all rule last. It reports whether the configuration changed.
+	UpsertTunnelRoutes(ctx context.Context, tunnelID, hostname string, rules []TunnelIngressRule) (bool, error)
+
+	// GetTunnelRoutes returns the rules for hostname in a tunnel's
+	// configuration, in order.
+	GetTunnelRoutes(ctx context.Context, tunnelID, hostname string) ([]TunnelIngressRule, error)
+
+	// DeleteTunnelRoute removes the rules for hostname from the tunnel's
+	// configuration. A missing tunnel or rule is not an error.
+	DeleteTunnelRoute(ctx context.Context, tunnelID, hostname string) error
+
+	// FindZoneID returns the ID of the zone containing host.
+	FindZoneID(ctx context.Context, host string) (string, error)
+
+	// UpsertCNAME creates or updates the proxied CNAME record name → target
+	// and returns its ID. It reports whether the record changed, and fails
+	// with a *DNSConflictError if name already has a record the operator did
+	// not create.
+	UpsertCNAME(ctx context.Context, zoneID, name, target string) (string, bool, error)
+
+	// FindDNSRecord returns the first DNS record named name in a zone, or nil
+	// if there is none.
+	FindDNSRecord(ctx context.Context, zoneID, name string) (*DNSRecord, error)
+
+	// DeleteDNSRecord deletes a DNS record. Deleting a record that no longer
+	// exists is not an error.
+	DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error
 
 	// CreateBypassApp creates a self-hosted Access Application with a bypass policy
-	// that allows unauthenticated access. The domain should include the path
+	// that allows unauthenticated access. The domains should include the path
 	// (e.g. "example.com/webhook").
-	CreateBypassApp(ctx context.Context, name, domain string) (*AccessApp, error)
+	CreateBypassApp(ctx context.Context, name string, domains []string) (*AccessApp, error)
+
+	// DetectBypassDrift fetches a bypass Access Application and compares its
+	// name and domains against the desired ones.
+	DetectBypassDrift(ctx context.Context, appID, name string, domains []string) (*Drift, error)
+
+	// UpdateBypassApp updates the name and domains of a bypass Access
+	// Application.
+	UpdateBypassApp(ctx context.Context, appID, name string, domains []string) error
 }
 
 // NewClient creates a Cloudflare API client.
 func NewClient(apiToken, accountID string) Client {
 	return &httpClient{
+		baseURL:   baseURL,
 		apiToken:  apiToken,
 		accountID: accountID,
 		http:      &http.Client{},
@@ -63,33 +212,49 @@ func NewClient(apiToken, accountID string) Client {
 }
 
 type httpClient struct {
+	baseURL   string
 	apiToken  string
 	accountID string
 	http      *http.Client
 }
 
 func (c *httpClient) do(ctx context.Context, method, path string, body any) ([]byte, error) {
-	var reqBody io.Reader
+	var payload []byte
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("marshal request: %w", err)
 		}
-		reqBody = bytes.NewReader(b)
+		payload = b
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reqBody)
+	return retry.Do(ctx, retry.Idempotent(method), func() ([]byte, error) {
+		return c.send(ctx, method, path, payload)
+	})
+}
+
+// send performs a single request and converts non-2xx responses into *APIError.
+func (c *httpClient) send(ctx context.Context, method, path string, payload []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+c.apiToken)
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	resp, err := c.http.Do(req)
 	if err != nil {
+		metrics.ObserveAPIRequest("cloudflare", method, path, 0, time.Since(start))
 		return nil, fmt.Errorf("execute request: %w", err)
 	}
 	defer resp.Body.Close()
+	metrics.ObserveAPIRequest("cloudflare", method, path, resp.StatusCode, time.Since(start))
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -97,50 +262,124 @@ func (c *httpClient) do(ctx context.Context, method, path string, body any) ([]b
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("cloudflare API %s %s returned %d: %s", method, path, resp.StatusCode, string(respBody))
+		apiErr := &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			RetryAfter: retry.ParseRetryAfter(resp.Header.Get("Retry-After")),
+			Body:       string(respBody),
+		}
+		var envelope struct {
+			Errors []ErrorDetail `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &envelope) == nil {
+			apiErr.Errors = envelope.Errors
+		}
+		return nil, apiErr
 	}
 
 	return respBody, nil
 }
 
+// listAll fetches every page of a paginated list endpoint, following
+// result_info.total_pages, and returns the raw result items.
+func (c *httpClient) listAll(ctx context.Context, path string, query url.Values) ([]json.RawMessage, error) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("per_page", strconv.Itoa(listPageSize))
+
+	var items []json.RawMessage
+	for page := 1; ; page++ {
+		q.Set("page", strconv.Itoa(page))
+		respBody, err := c.do(ctx, http.MethodGet, path+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Result     []json.RawMessage `json:"result"`
+			ResultInfo *struct {
+				Page       int `json:"page"`
+				TotalPages int `json:"total_pages"`
+			} `json:"result_info"`
+		}
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return nil, fmt.Errorf("unmarshal page %d: %w", page, err)
+		}
+		items = append(items, result.Result...)
+
+		if result.ResultInfo == nil || len(result.Result) == 0 || page >= result.ResultInfo.TotalPages {
+			return items, nil
+		}
+	}
+}
+
 func (c *httpClient) accountPath(suffix string) string {
 	return fmt.Sprintf("/accounts/%s/access%s", c.accountID, suffix)
 }
 
 func (c *httpClient) FindAccessAppByDomain(ctx context.Context, domain string) (*AccessApp, error) {
-	respBody, err := c.do(ctx, http.MethodGet, c.accountPath("/apps"), nil)
+	// The domain filter narrows the listing server-side; the result is still
+	// matched exactly since the API may return partial matches.
+	query := url.Values{"domain": {domain}}
+	items, err := c.listAll(ctx, c.accountPath("/apps"), query)
 	if err != nil {
 		return nil, fmt.Errorf("list access apps: %w", err)
 	}
 
+	for _, item := range items {
+		var app AccessApp
+		if err := json.Unmarshal(item, &app); err != nil {
+			return nil, fmt.Errorf("unmarshal access app: %w", err)
+		}
+		if app.Domain == domain {
+			return &app, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *httpClient) GetAccessApp(ctx context.Context, appID string) (*AccessApp, error) {
+	respBody, err := c.do(ctx, http.MethodGet, c.accountPath("/apps/"+appID), nil)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get access app: %w", err)
+	}
+
 	var result struct {
-		Result []struct {
-			ID     string `json:"id"`
-			Name   string `json:"name"`
-			Domain string `json:"domain"`
-		} `json:"result"`
+		Result AccessApp `json:"result"`
 	}
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("unmarshal access apps: %w", err)
+		return nil, fmt.Errorf("unmarshal access app: %w", err)
 	}
+	return &result.Result, nil
+}
 
-	for _, app := range result.Result {
-		if app.Domain == domain {
-			return &AccessApp{ID: app.ID, Name: app.Name}, nil
+func (c *httpClient) GetAccessPolicy(ctx context.Context, appID, policyID string) (*AccessPolicy, error) {
+	path := c.accountPath(fmt.Sprintf("/apps/%s/policies/%s", appID, policyID))
+	respBody, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("get access policy: %w", err)
 	}
-	return nil, nil
-}
 
-func (c *httpClient) CreateAccessApp(ctx context.Context, name, domain, sessionDuration string) (*AccessApp, error) {
-	body := map[string]any{
-		"name":             name,
-		"domain":           domain,
-		"type":             "self_hosted",
-		"session_duration": sessionDuration,
+	var result struct {
+		Result AccessPolicy `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal access policy: %w", err)
 	}
+	return &result.Result, nil
+}
 
-	respBody, err := c.do(ctx, http.MethodPost, c.accountPath("/apps"), body)
+func (c *httpClient) CreateAccessApp(ctx context.Context, name string, domains []string, sessionDuration string) (*AccessApp, error) {
+	respBody, err := c.do(ctx, http.MethodPost, c.accountPath("/apps"), AccessAppBody(name, domains, sessionDuration))
 	if err != nil {
 		return nil, fmt.Errorf("create access app: %w", err)
 	}
@@ -158,14 +397,11 @@ func (c *httpClient) CreateAccessApp(ctx context.Context, name, domain, sessionD
 	return &AccessApp{ID: result.Result.ID, Name: result.Result.Name}, nil
 }
 
-func (c *httpClient) UpdateAccessApp(ctx context.Context, appID, name, domain, sessionDuration string) error {
-	body := map[string]any{
-		"name":             name,
-		"domain":           domain,
-		"type":             "self_hosted",
-		"session_duration": sessionDuration,
+func (c *httpClient) UpdateAccessApp(ctx context.Context, appID, name string, domains []string, sessionDuration string, policies []AppPolicyLink) error {
+	body := AccessAppBody(name, domains, sessionDuration)
+	if policies != nil {
+		body["policies"] = policies
 	}
-
 	_, err := c.do(ctx, http.MethodPut, c.accountPath("/apps/"+appID), body)
 	if err != nil {
 		return fmt.Errorf("update access app: %w", err)
@@ -175,39 +411,27 @@ func (c *httpClient) UpdateAccessApp(ctx context.Context, appID, name, domain, s
 
 func (c *httpClient) DeleteAccessApp(ctx context.Context, appID string) error {
 	_, err := c.do(ctx, http.MethodDelete, c.accountPath("/apps/"+appID), nil)
-	if err != nil {
+	if err != nil && !IsNotFound(err) {
 		return fmt.Errorf("delete access app: %w", err)
 	}
 	return nil
 }
 
-func (c *httpClient) UpsertAccessPolicy(ctx context.Context, appID string, existingPolicyID string, rules []OIDCClaimRule) (*AccessPolicy, error) {
-	include := make([]map[string]any, len(rules))
-	for i, rule := range rules {
-		include[i] = map[string]any{
-			"oidc": map[string]any{
-				"identity_provider_id": rule.IdentityProviderID,
-				"claim_name":           rule.ClaimName,
-				"claim_value":          rule.ClaimValue,
-			},
-		}
-	}
-
-	body := map[string]any{
-		"name":       "Allow Zitadel roles",
-		"decision":   "allow",
-		"precedence": 1,
-		"include":    include,
-	}
+func (c *httpClient) UpsertAccessPolicy(ctx context.Context, appID string, existingPolicyID string, policy AccessPolicy) (*AccessPolicy, error) {
+	body := policy
+	body.ID = ""
 
 	if existingPolicyID != "" {
 		// Update existing policy.
 		path := c.accountPath(fmt.Sprintf("/apps/%s/policies/%s", appID, existingPolicyID))
 		_, err := c.do(ctx, http.MethodPut, path, body)
-		if err != nil {
+		if err == nil {
+			return &AccessPolicy{ID: existingPolicyID}, nil
+		}
+		if !IsNotFound(err) {
 			return nil, fmt.Errorf("update access policy: %w", err)
 		}
-		return &AccessPolicy{ID: existingPolicyID}, nil
+		// Deleted outside the operator; create it again below.
 	}
 
 	// Create new policy.
@@ -229,15 +453,17 @@ func (c *httpClient) UpsertAccessPolicy(ctx context.Context, appID string, exist
 	return &AccessPolicy{ID: result.Result.ID}, nil
 }
 
-func (c *httpClient) CreateBypassApp(ctx context.Context, name, domain string) (*AccessApp, error) {
-	body := map[string]any{
-		"name":             name,
-		"domain":           domain,
-		"type":             "self_hosted",
-		"session_duration": "24h",
+func (c *httpClient) DeleteAccessPolicy(ctx context.Context, appID, policyID string) error {
+	path := c.accountPath(fmt.Sprintf("/apps/%s/policies/%s", appID, policyID))
+	_, err := c.do(ctx, http.MethodDelete, path, nil)
+	if err != nil && !IsNotFound(err) {
+		return fmt.Errorf("delete access policy: %w", err)
 	}
+	return nil
+}
 
-	respBody, err := c.do(ctx, http.MethodPost, c.accountPath("/apps"), body)
+func (c *httpClient) CreateBypassApp(ctx context.Context, name string, domains []string) (*AccessApp, error) {
+	respBody, err := c.do(ctx, http.MethodPost, c.accountPath("/apps"), BypassAppBody(name, domains))
 	if err != nil {
 		return nil, fmt.Errorf("create bypass access app: %w", err)
 	}
@@ -253,14 +479,8 @@ func (c *httpClient) CreateBypassApp(ctx context.Context, name, domain string) (
 	}
 
 	// Create bypass policy on the app.
-	policyBody := map[string]any{
-		"name":       "Bypass",
-		"decision":   "bypass",
-		"precedence": 1,
-		"include":    []map[string]any{{"everyone": map[string]any{}}},
-	}
 	path := c.accountPath(fmt.Sprintf("/apps/%s/policies", result.Result.ID))
-	if _, err := c.do(ctx, http.MethodPost, path, policyBody); err != nil {
+	if _, err := c.do(ctx, http.MethodPost, path, BypassPolicyBody()); err != nil {
 		// Clean up the app if policy creation fails.
 		_ = c.DeleteAccessApp(ctx, result.Result.ID)
 		return nil, fmt.Errorf("create bypass policy: %w", err)
@@ -268,3 +488,10 @@ func (c *httpClient) CreateBypassApp(ctx context.Context, name, domain string) (
 
 	return &AccessApp{ID: result.Result.ID, Name: result.Result.Name}, nil
 }
+
+func (c *httpClient) UpdateBypassApp(ctx context.Context, appID, name string, domains []string) error {
+	if _, err := c.do(ctx, http.MethodPut, c.accountPath("/apps/"+appID), BypassAppBody(name, domains)); err != nil {
+		return fmt.Errorf("update bypass access app: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// newTestClient returns a client talking to srv.
+func newTestClient(srv *httptest.Server) *httpClient {
+	return &httpClient{
+		baseURL:   srv.URL,
+		apiToken:  "token",
+		accountID: "acc",
+		http:      srv.Client(),
+	}
+}
+
+// servePages serves pages of Access apps like the Cloudflare list endpoint,
+// recording the requested page numbers.
+func servePages(t *testing.T, pages [][]AccessApp, requested *[]int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/accounts/acc/access/apps" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.URL.Query().Get("per_page"); got != strconv.Itoa(listPageSize) {
+			t.Errorf("per_page = %q, want %d", got, listPageSize)
+		}
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 || page > len(pages) {
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+			http.NotFound(w, r)
+			return
+		}
+		*requested = append(*requested, page)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"result":  pages[page-1],
+			"result_info": map[string]any{
+				"page":        page,
+				"per_page":    listPageSize,
+				"total_pages": len(pages),
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFindAccessAppByDomainPastFirstPage(t *testing.T) {
+	pages := [][]AccessApp{
+		{{ID: "a1", Domain: "other.example.com"}, {ID: "a2", Domain: "wiki.example.com.evil.net"}},
+		{{ID: "b1", Domain: "blog.example.com"}, {ID: "b2", Domain: "wiki.example.com"}},
+		{{ID: "c1", Domain: "wiki.example.com/admin"}},
+	}
+	var requested []int
+	c := newTestClient(servePages(t, pages, &requested))
+
+	app, err := c.FindAccessAppByDomain(context.Background(), "wiki.example.com")
+	if err != nil {
+		t.Fatalf("FindAccessAppByDomain: %v", err)
+	}
+	if app == nil || app.ID != "b2" {
+		t.Fatalf("FindAccessAppByDomain = %+v, want app b2 from page 2", app)
+	}
+	if len(requested) < 2 || requested[0] != 1 || requested[1] != 2 {
+		t.Errorf("requested pages %v, want 1 and 2 in order", requested)
+	}
+}
+
+func TestFindAccessAppByDomainOnLastPage(t *testing.T) {
+	pages := [][]AccessApp{
+		{{ID: "a1", Domain: "a.example.com"}},
+		{{ID: "b1", Domain: "b.example.com"}},
+		{{ID: "c1", Domain: "wiki.example.com"}},
+	}
+	var requested []int
+	c := newTestClient(servePages(t, pages, &requested))
+
+	app, err := c.FindAccessAppByDomain(context.Background(), "wiki.example.com")
+	if err != nil {
+		t.Fatalf("FindAccessAppByDomain: %v", err)
+	}
+	if app == nil || app.ID != "c1" {
+		t.Fatalf("FindAccessAppByDomain = %+v, want app c1 from page 3", app)
+	}
+	if len(requested) != 3 {
+		t.Errorf("requested pages %v, want all 3", requested)
+	}
+}
+
+func TestFindAccessAppByDomainNoMatch(t *testing.T) {
+	pages := [][]AccessApp{
+		{{ID: "a1", Domain: "a.example.com"}},
+		{{ID: "b1", Domain: "wiki.example.com.evil.net"}},
+	}
+	var requested []int
+	c := newTestClient(servePages(t, pages, &requested))
+
+	app, err := c.FindAccessAppByDomain(context.Background(), "wiki.example.com")
+	if err != nil {
+		t.Fatalf("FindAccessAppByDomain: %v", err)
+	}
+	if app != nil {
+		t.Fatalf("FindAccessAppByDomain = %+v, want nil", app)
+	}
+	if len(requested) != 2 {
+		t.Errorf("requested pages %v, want both", requested)
+	}
+}
+
+func TestRetryOnlyIdempotentRequests(t *testing.T) {
+	cases := []struct {
+		method   string
+		status   int
+		attempts int
+	}{
+		{http.MethodGet, http.StatusBadGateway, 2},
+		{http.MethodPut, http.StatusServiceUnavailable, 2},
+		{http.MethodDelete, http.StatusInternalServerError, 2},
+		{http.MethodPost, http.StatusTooManyRequests, 2},
+		{http.MethodPost, http.StatusBadGateway, 1},
+		{http.MethodGet, http.StatusBadRequest, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+strconv.Itoa(tc.status), func(t *testing.T) {
+			attempts := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts == 1 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "result": map[string]any{}})
+			}))
+			t.Cleanup(srv.Close)
+
+			_, _ = newTestClient(srv).do(context.Background(), tc.method, "/accounts/acc/access/apps", nil)
+			if attempts != tc.attempts {
+				t.Errorf("sent %d times, want %d", attempts, tc.attempts)
+			}
+		})
+	}
+}
+
+func TestUpsertCNAMEKeepsForeignRecords(t *testing.T) {
+	cases := []struct {
+		name   string
+		record DNSRecord
+	}{
+		{"CNAME without comment", DNSRecord{ID: "rec1", Type: "CNAME", Name: "api.example.com", Content: "elsewhere.example.net", Proxied: true}},
+		{"A record", DNSRecord{ID: "rec1", Type: "A", Name: "api.example.com", Content: "192.0.2.1", Comment: dnsRecordComment}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"success":     true,
+					"result":      []DNSRecord{tc.record},
+					"result_info": map[string]any{"page": 1, "total_pages": 1},
+				})
+			}))
+			t.Cleanup(srv.Close)
+
+			_, _, err := newTestClient(srv).UpsertCNAME(context.Background(), "zone1", "api.example.com", "tunnel1.cfargotunnel.com")
+			var conflict *DNSConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("err = %v, want *DNSConflictError", err)
+			}
+		})
+	}
+}
+
+func TestSameTunnelRules(t *testing.T) {
+	rule := TunnelIngressRule{Hostname: "api.example.com", Path: "^/api", Service: "http://api.default:8080"}
+	withOrigin := rule
+	withOrigin.OriginRequest = map[string]any{"noTLSVerify": true}
+	var decoded TunnelIngressRule
+	if err := json.Unmarshal([]byte(`{"hostname":"api.example.com","path":"^/api","service":"http://api.default:8080","originRequest":{"noTLSVerify":true}}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		a, b []TunnelIngressRule
+		want bool
+	}{
+		{"same", []TunnelIngressRule{rule}, []TunnelIngressRule{rule}, true},
+		{"different service", []TunnelIngressRule{rule}, []TunnelIngressRule{{Hostname: rule.Hostname, Path: rule.Path, Service: "http://other.default:8080"}}, false},
+		{"origin request added", []TunnelIngressRule{withOrigin}, []TunnelIngressRule{rule}, false},
+		{"origin request decoded", []TunnelIngressRule{decoded}, []TunnelIngressRule{withOrigin}, true},
+		{"extra rule", []TunnelIngressRule{rule, rule}, []TunnelIngressRule{rule}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SameTunnelRules(tc.a, tc.b); got != tc.want {
+				t.Errorf("SameTunnelRules = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
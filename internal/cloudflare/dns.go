@@ -0,0 +1,127 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// dnsRecordComment marks the DNS records created by the operator.
+const dnsRecordComment = "Managed by cf-zitadel-access-operator"
+
+// DNSRecord is a DNS record in a Cloudflare zone.
+type DNSRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Proxied bool   `json:"proxied"`
+	TTL     int    `json:"ttl,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// Managed reports whether the operator created the record.
+func (r *DNSRecord) Managed() bool {
+	return r.Comment == dnsRecordComment
+}
+
+// DNSConflictError is returned when a hostname already has a DNS record the
+// operator did not create. The record is left alone, since deleting the
+// route later would delete it too.
+type DNSConflictError struct {
+	Name string
+	Type string
+}
+
+func (e *DNSConflictError) Error() string {
+	return fmt.Sprintf("%s already has a %s record not managed by the operator; remove it to route the host through the tunnel", e.Name, e.Type)
+}
+
+func (c *httpClient) FindZoneID(ctx context.Context, host string) (string, error) {
+	// Try the host's parent domains from the longest down, so that
+	// delegated subdomain zones win over the apex zone.
+	labels := strings.Split(host, ".")
+	for i := 0; i < len(labels)-1; i++ {
+		name := strings.Join(labels[i:], ".")
+		items, err := c.listAll(ctx, "/zones", url.Values{"name": {name}})
+		if err != nil {
+			return "", fmt.Errorf("list zones: %w", err)
+		}
+		for _, raw := range items {
+			var zone struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(raw, &zone); err != nil {
+				return "", fmt.Errorf("unmarshal zone: %w", err)
+			}
+			if zone.Name == name {
+				return zone.ID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no Cloudflare zone found for %s", host)
+}
+
+func (c *httpClient) FindDNSRecord(ctx context.Context, zoneID, name string) (*DNSRecord, error) {
+	items, err := c.listAll(ctx, "/zones/"+zoneID+"/dns_records", url.Values{"name": {name}})
+	if err != nil {
+		return nil, fmt.Errorf("list DNS records: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	var record DNSRecord
+	if err := json.Unmarshal(items[0], &record); err != nil {
+		return nil, fmt.Errorf("unmarshal DNS record: %w", err)
+	}
+	return &record, nil
+}
+
+func (c *httpClient) UpsertCNAME(ctx context.Context, zoneID, name, target string) (string, bool, error) {
+	items, err := c.listAll(ctx, "/zones/"+zoneID+"/dns_records", url.Values{"name": {name}})
+	if err != nil {
+		return "", false, fmt.Errorf("list DNS records: %w", err)
+	}
+
+	desired := DNSRecord{Type: "CNAME", Name: name, Content: target, Proxied: true, TTL: 1, Comment: dnsRecordComment}
+	for _, raw := range items {
+		var record DNSRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return "", false, fmt.Errorf("unmarshal DNS record: %w", err)
+		}
+		if record.Type != "CNAME" || !record.Managed() {
+			return "", false, &DNSConflictError{Name: name, Type: record.Type}
+		}
+		if record.Content == target && record.Proxied {
+			return record.ID, false, nil
+		}
+		if _, err := c.do(ctx, http.MethodPut, "/zones/"+zoneID+"/dns_records/"+record.ID, desired); err != nil {
+			return "", false, fmt.Errorf("update DNS record: %w", err)
+		}
+		return record.ID, true, nil
+	}
+
+	respBody, err := c.do(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", desired)
+	if err != nil {
+		return "", false, fmt.Errorf("create DNS record: %w", err)
+	}
+	var result struct {
+		Result DNSRecord `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", false, fmt.Errorf("unmarshal DNS record response: %w", err)
+	}
+	return result.Result.ID, true, nil
+}
+
+func (c *httpClient) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+recordID, nil)
+	if err != nil && !IsNotFound(err) {
+		return fmt.Errorf("delete DNS record: %w", err)
+	}
+	return nil
+}
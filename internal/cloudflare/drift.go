@@ -0,0 +1,178 @@
+package cloudflare
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// DesiredAccessApp is the state the operator wants for an Access Application
+// and its allow policy. The first of Domains is the primary domain.
+type DesiredAccessApp struct {
+	Name            string
+	Domains         []string
+	SessionDuration string
+	Policy          PolicyRules
+
+	// ReusablePolicies lists the IDs of the account-level policies that
+	// must be attached, in order. Nil leaves attached policies unchecked.
+	ReusablePolicies []string
+}
+
+// Drift describes how the live Access Application and policy differ from the
+// desired state.
+type Drift struct {
+	// AppMissing is set when the Access Application no longer exists.
+	AppMissing bool
+
+	// PolicyMissing is set when the allow policy no longer exists.
+	PolicyMissing bool
+
+	// GroupMissing is set when an Access group no longer exists.
+	GroupMissing bool
+
+	// ReusablePoliciesChanged is set when the attached account-level
+	// policies differ from DesiredAccessApp.ReusablePolicies.
+	ReusablePoliciesChanged bool
+
+	// Changes lists human-readable differences, e.g. `domain: "a" → "b"`.
+	Changes []string
+}
+
+// Drifted reports whether anything differs from the desired state.
+func (d *Drift) Drifted() bool {
+	return d.AppMissing || d.PolicyMissing || d.GroupMissing || len(d.Changes) > 0
+}
+
+func (c *httpClient) DetectDrift(ctx context.Context, appID, policyID string, desired DesiredAccessApp) (*Drift, error) {
+	drift := &Drift{}
+
+	app, err := c.GetAccessApp(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		drift.AppMissing = true
+		drift.Changes = append(drift.Changes, fmt.Sprintf("access application %s was deleted", appID))
+		return drift, nil
+	}
+	drift.Changes = append(drift.Changes, diffAccessApp(app, desired)...)
+	if desired.ReusablePolicies != nil {
+		if change := diffReusablePolicies(app.Policies, desired.ReusablePolicies); change != "" {
+			drift.ReusablePoliciesChanged = true
+			drift.Changes = append(drift.Changes, change)
+		}
+	}
+
+	// Without include rules no allow policy is wanted; the caller removes
+	// an existing one.
+	if len(desired.Policy.Include) == 0 {
+		return drift, nil
+	}
+	policyDrift, err := c.DetectPolicyDrift(ctx, appID, policyID, AllowPolicyBody(desired.Policy))
+	if err != nil {
+		return nil, err
+	}
+	drift.PolicyMissing = policyDrift.PolicyMissing
+	drift.Changes = append(drift.Changes, policyDrift.Changes...)
+
+	return drift, nil
+}
+
+func (c *httpClient) DetectPolicyDrift(ctx context.Context, appID, policyID string, desired AccessPolicy) (*Drift, error) {
+	drift := &Drift{}
+	if policyID == "" {
+		drift.PolicyMissing = true
+		return drift, nil
+	}
+	policy, err := c.GetAccessPolicy(ctx, appID, policyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		drift.PolicyMissing = true
+		drift.Changes = append(drift.Changes, fmt.Sprintf("access policy %s was deleted", policyID))
+		return drift, nil
+	}
+	drift.Changes = diffAccessPolicy(policy, desired)
+	return drift, nil
+}
+
+func (c *httpClient) DetectBypassDrift(ctx context.Context, appID, name string, domains []string) (*Drift, error) {
+	app, err := c.GetAccessApp(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return &Drift{
+			AppMissing: true,
+			Changes:    []string{fmt.Sprintf("bypass access application %s was deleted", appID)},
+		}, nil
+	}
+	desired := DesiredAccessApp{Name: name, Domains: domains, SessionDuration: bypassSessionDuration}
+	return &Drift{Changes: diffAccessApp(app, desired)}, nil
+}
+
+func diffAccessApp(app *AccessApp, desired DesiredAccessApp) []string {
+	var changes []string
+	if app.Name != desired.Name {
+		changes = append(changes, fmt.Sprintf("name: %q → %q", app.Name, desired.Name))
+	}
+	if app.Domain != desired.Domains[0] {
+		changes = append(changes, fmt.Sprintf("domain: %q → %q", app.Domain, desired.Domains[0]))
+	}
+	// A single-domain app has the domain as its only destination, so
+	// destinations only need comparing when either side has more.
+	var live []string
+	for _, destination := range app.Destinations {
+		if destination.Type == "public" {
+			live = append(live, destination.URI)
+		}
+	}
+	if (len(live) > 1 || len(desired.Domains) > 1) && !slices.Equal(live, desired.Domains) {
+		changes = append(changes, fmt.Sprintf("destinations: %q → %q", live, desired.Domains))
+	}
+	if app.SessionDuration != desired.SessionDuration {
+		changes = append(changes, fmt.Sprintf("session_duration: %q → %q", app.SessionDuration, desired.SessionDuration))
+	}
+	return changes
+}
+
+// diffReusablePolicies compares the attached account-level policies, ordered
+// by precedence, against the desired IDs.
+func diffReusablePolicies(attached []AppPolicyLink, desired []string) string {
+	var reusable []AppPolicyLink
+	for _, link := range attached {
+		if link.Reusable {
+			reusable = append(reusable, link)
+		}
+	}
+	slices.SortStableFunc(reusable, func(a, b AppPolicyLink) int { return cmp.Compare(a.Precedence, b.Precedence) })
+
+	live := make([]string, len(reusable))
+	for i, link := range reusable {
+		live[i] = link.ID
+	}
+	if slices.Equal(live, desired) {
+		return ""
+	}
+	return fmt.Sprintf("reusable policies: %v → %v", live, desired)
+}
+
+func diffAccessPolicy(policy *AccessPolicy, desired AccessPolicy) []string {
+	var changes []string
+	if policy.Decision != desired.Decision {
+		changes = append(changes, fmt.Sprintf("policy decision: %q → %q", policy.Decision, desired.Decision))
+	}
+	if desired.Precedence != 0 && policy.Precedence != desired.Precedence {
+		changes = append(changes, fmt.Sprintf("policy precedence: %d → %d", policy.Precedence, desired.Precedence))
+	}
+	if desired.SessionDuration != "" && policy.SessionDuration != desired.SessionDuration {
+		changes = append(changes, fmt.Sprintf("policy session_duration: %q → %q", policy.SessionDuration, desired.SessionDuration))
+	}
+	changes = append(changes, diffRules("policy include", policy.Include, desired.Include)...)
+	changes = append(changes, diffRules("policy require", policy.Require, desired.Require)...)
+	changes = append(changes, diffRules("policy exclude", policy.Exclude, desired.Exclude)...)
+	return changes
+}
@@ -0,0 +1,74 @@
+package cloudflare
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrorDetail is a single entry of the "errors" array in a Cloudflare API response.
+type ErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// APIError is returned for non-2xx responses from the Cloudflare API.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+
+	// Errors holds the error entries from the response envelope, if any.
+	Errors []ErrorDetail
+
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+
+	// Body is the raw response body, used when it could not be decoded.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("cloudflare API %s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+	}
+	msgs := make([]string, len(e.Errors))
+	for i, d := range e.Errors {
+		msgs[i] = fmt.Sprintf("%s (code %d)", d.Message, d.Code)
+	}
+	return fmt.Sprintf("cloudflare API %s %s returned %d: %s", e.Method, e.Path, e.StatusCode, strings.Join(msgs, "; "))
+}
+
+// Retryable reports whether the request may succeed if sent again later.
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// HTTPStatus returns the response status code, for retry.Do.
+func (e *APIError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// RetryDelay returns the delay requested by the Retry-After header, for
+// retry.Do.
+func (e *APIError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// HasCode reports whether the response carried the given Cloudflare error code.
+func (e *APIError) HasCode(code int) bool {
+	for _, d := range e.Errors {
+		if d.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
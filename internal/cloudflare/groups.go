@@ -0,0 +1,46 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+)
+
+const accessGroupKind = "access group"
+
+// AccessGroup represents a Cloudflare Access group: a named set of rules
+// that policies match with a group rule. It is also the request body when
+// creating or updating one.
+type AccessGroup struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name"`
+	Include []Rule `json:"include"`
+	Require []Rule `json:"require,omitempty"`
+	Exclude []Rule `json:"exclude,omitempty"`
+}
+
+func (c *httpClient) DetectGroupDrift(ctx context.Context, groupID string, desired AccessGroup) (*Drift, error) {
+	drift, missing, err := detectAccountObjectDrift(ctx, c, "/groups", groupID, accessGroupKind, func(group *AccessGroup) []string {
+		var changes []string
+		if group.Name != desired.Name {
+			changes = append(changes, fmt.Sprintf("group name: %q → %q", group.Name, desired.Name))
+		}
+		changes = append(changes, diffRules("group include", group.Include, desired.Include)...)
+		changes = append(changes, diffRules("group require", group.Require, desired.Require)...)
+		return append(changes, diffRules("group exclude", group.Exclude, desired.Exclude)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	drift.GroupMissing = missing
+	return drift, nil
+}
+
+func (c *httpClient) UpsertAccessGroup(ctx context.Context, existingGroupID string, group AccessGroup) (string, error) {
+	body := group
+	body.ID = ""
+	return c.upsertAccountObject(ctx, "/groups", existingGroupID, accessGroupKind, body)
+}
+
+func (c *httpClient) DeleteAccessGroup(ctx context.Context, groupID string) error {
+	return c.deleteAccountObject(ctx, "/groups", groupID, accessGroupKind)
+}
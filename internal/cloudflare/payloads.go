@@ -0,0 +1,74 @@
+package cloudflare
+
+// Request bodies are built by these functions so that they can be rendered
+// without a client (see the render command).
+
+const (
+	allowPolicyName = "Allow Zitadel roles"
+
+	// bypassSessionDuration is the session duration of bypass apps. It has no
+	// effect since bypass policies never start a session.
+	bypassSessionDuration = "24h"
+)
+
+// AccessAppBody returns the request body that creates or updates a
+// self-hosted Access Application. Destinations are only sent for more than
+// one domain; otherwise Cloudflare derives the single one from domain.
+func AccessAppBody(name string, domains []string, sessionDuration string) map[string]any {
+	body := map[string]any{
+		"name":             name,
+		"domain":           domains[0],
+		"type":             "self_hosted",
+		"session_duration": sessionDuration,
+	}
+	if len(domains) > 1 {
+		body["destinations"] = Destinations(domains)
+	}
+	return body
+}
+
+// Destinations returns the public destinations for domains.
+func Destinations(domains []string) []Destination {
+	destinations := make([]Destination, 0, len(domains))
+	for _, domain := range domains {
+		destinations = append(destinations, Destination{Type: "public", URI: domain})
+	}
+	return destinations
+}
+
+// AllowPolicyBody returns the request body of the allow policy.
+func AllowPolicyBody(rules PolicyRules) AccessPolicy {
+	return AccessPolicy{
+		Name:       allowPolicyName,
+		Decision:   "allow",
+		Precedence: 1,
+		Include:    rules.Include,
+		Require:    rules.Require,
+		Exclude:    rules.Exclude,
+	}
+}
+
+// ServiceTokenBody returns the request body that creates or updates a
+// service token.
+func ServiceTokenBody(name, duration string) map[string]any {
+	return map[string]any{
+		"name":     name,
+		"duration": duration,
+	}
+}
+
+// BypassAppBody returns the request body that creates a bypass Access Application.
+func BypassAppBody(name string, domains []string) map[string]any {
+	return AccessAppBody(name, domains, bypassSessionDuration)
+}
+
+// BypassPolicyBody returns the request body of the policy that lets everyone
+// through a bypass Access Application.
+func BypassPolicyBody() AccessPolicy {
+	return AccessPolicy{
+		Name:       "Bypass",
+		Decision:   "bypass",
+		Precedence: 1,
+		Include:    []Rule{{Everyone: &struct{}{}}},
+	}
+}
@@ -0,0 +1,37 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reusable policies live at the account level and are attached to Access
+// Applications by ID, instead of belonging to a single application.
+
+const reusablePolicyKind = "reusable policy"
+
+func (c *httpClient) DetectReusablePolicyDrift(ctx context.Context, policyID string, desired AccessPolicy) (*Drift, error) {
+	drift, missing, err := detectAccountObjectDrift(ctx, c, "/policies", policyID, reusablePolicyKind, func(policy *AccessPolicy) []string {
+		var changes []string
+		if policy.Name != desired.Name {
+			changes = append(changes, fmt.Sprintf("policy name: %q → %q", policy.Name, desired.Name))
+		}
+		return append(changes, diffAccessPolicy(policy, desired)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	drift.PolicyMissing = missing
+	return drift, nil
+}
+
+func (c *httpClient) UpsertReusablePolicy(ctx context.Context, existingPolicyID string, policy AccessPolicy) (string, error) {
+	body := policy
+	body.ID = ""
+	body.Precedence = 0
+	return c.upsertAccountObject(ctx, "/policies", existingPolicyID, reusablePolicyKind, body)
+}
+
+func (c *httpClient) DeleteReusablePolicy(ctx context.Context, policyID string) error {
+	return c.deleteAccountObject(ctx, "/policies", policyID, reusablePolicyKind)
+}
@@ -0,0 +1,158 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Rule is one Access rule selector. Exactly one field is set; it is encoded
+// as the single-key object the API expects, e.g. {"email": {"email": "a@b"}}.
+type Rule struct {
+	Email                *EmailRule         `json:"email,omitempty"`
+	EmailDomain          *EmailDomainRule   `json:"email_domain,omitempty"`
+	IP                   *IPRule            `json:"ip,omitempty"`
+	Geo                  *GeoRule           `json:"geo,omitempty"`
+	Group                *GroupRule         `json:"group,omitempty"`
+	ServiceToken         *ServiceTokenRule  `json:"service_token,omitempty"`
+	AnyValidServiceToken *struct{}          `json:"any_valid_service_token,omitempty"`
+	Certificate          *struct{}          `json:"certificate,omitempty"`
+	CommonName           *CommonNameRule    `json:"common_name,omitempty"`
+	DevicePosture        *DevicePostureRule `json:"device_posture,omitempty"`
+	OIDC                 *OIDCClaimRule     `json:"oidc,omitempty"`
+	Everyone             *struct{}          `json:"everyone,omitempty"`
+}
+
+// EmailRule matches a single email address.
+type EmailRule struct {
+	Email string `json:"email"`
+}
+
+// EmailDomainRule matches every email address of a domain.
+type EmailDomainRule struct {
+	Domain string `json:"domain"`
+}
+
+// IPRule matches a client IP address or CIDR range.
+type IPRule struct {
+	IP string `json:"ip"`
+}
+
+// GeoRule matches the client's country (ISO 3166-1 alpha-2 code).
+type GeoRule struct {
+	CountryCode string `json:"country_code"`
+}
+
+// GroupRule matches members of an Access group.
+type GroupRule struct {
+	ID string `json:"id"`
+}
+
+// ServiceTokenRule matches one Access service token.
+type ServiceTokenRule struct {
+	TokenID string `json:"token_id"`
+}
+
+// CommonNameRule matches the common name of an mTLS client certificate.
+type CommonNameRule struct {
+	CommonName string `json:"common_name"`
+}
+
+// DevicePostureRule matches devices passing a device posture check.
+type DevicePostureRule struct {
+	IntegrationUID string `json:"integration_uid"`
+}
+
+// OIDCClaimRule defines an inline OIDC claim check for an Access Policy.
+type OIDCClaimRule struct {
+	IdentityProviderID string `json:"identity_provider_id"`
+	ClaimName          string `json:"claim_name"`
+	ClaimValue         string `json:"claim_value"`
+}
+
+// PolicyRules are the rule lists of an Access policy. A request matches if
+// it matches any include rule, every require rule and no exclude rule.
+type PolicyRules struct {
+	Include []Rule
+	Require []Rule
+	Exclude []Rule
+}
+
+// String describes the rule for drift reports and events, e.g.
+// "oidc custom:roles=admin" or "email alice@example.com".
+func (r Rule) String() string {
+	switch {
+	case r.Email != nil:
+		return "email " + r.Email.Email
+	case r.EmailDomain != nil:
+		return "email_domain " + r.EmailDomain.Domain
+	case r.IP != nil:
+		return "ip " + r.IP.IP
+	case r.Geo != nil:
+		return "geo " + r.Geo.CountryCode
+	case r.Group != nil:
+		return "group " + r.Group.ID
+	case r.ServiceToken != nil:
+		return "service_token " + r.ServiceToken.TokenID
+	case r.AnyValidServiceToken != nil:
+		return "any_valid_service_token"
+	case r.Certificate != nil:
+		return "certificate"
+	case r.CommonName != nil:
+		return "common_name " + r.CommonName.CommonName
+	case r.DevicePosture != nil:
+		return "device_posture " + r.DevicePosture.IntegrationUID
+	case r.OIDC != nil:
+		return fmt.Sprintf("oidc %s=%s", r.OIDC.ClaimName, r.OIDC.ClaimValue)
+	case r.Everyone != nil:
+		return "everyone"
+	}
+	return "unsupported rule"
+}
+
+// key returns a canonical encoding of the rule, used to compare rule lists
+// regardless of order.
+func (r Rule) key() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return r.String()
+	}
+	return string(b)
+}
+
+// diffRules lists the rules missing from or unexpected in a live rule list,
+// prefixed with the list name (e.g. "policy include").
+func diffRules(list string, live, desired []Rule) []string {
+	want := make(map[string]int, len(desired))
+	for _, rule := range desired {
+		want[rule.key()]++
+	}
+
+	var unexpected []string
+	for _, rule := range live {
+		if k := rule.key(); want[k] > 0 {
+			want[k]--
+			continue
+		}
+		unexpected = append(unexpected, rule.String())
+	}
+
+	var missing []string
+	for _, rule := range desired {
+		if k := rule.key(); want[k] > 0 {
+			want[k]--
+			missing = append(missing, rule.String())
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	var changes []string
+	for _, m := range missing {
+		changes = append(changes, fmt.Sprintf("%s: missing %s", list, m))
+	}
+	for _, u := range unexpected {
+		changes = append(changes, fmt.Sprintf("%s: unexpected %s", list, u))
+	}
+	return changes
+}
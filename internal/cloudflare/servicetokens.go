@@ -0,0 +1,68 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ServiceToken represents a Cloudflare Access service token. The client
+// secret is only returned when the token is created or rotated.
+type ServiceToken struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	Duration     string    `json:"duration,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (c *httpClient) serviceTokenRequest(ctx context.Context, method, path, action string, body any) (*ServiceToken, error) {
+	respBody, err := c.do(ctx, method, c.accountPath(path), body)
+	if err != nil {
+		return nil, fmt.Errorf("%s service token: %w", action, err)
+	}
+
+	var result struct {
+		Result ServiceToken `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal service token: %w", err)
+	}
+	return &result.Result, nil
+}
+
+func (c *httpClient) GetServiceToken(ctx context.Context, tokenID string) (*ServiceToken, error) {
+	token, err := c.serviceTokenRequest(ctx, http.MethodGet, "/service_tokens/"+tokenID, "get", nil)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	return token, err
+}
+
+func (c *httpClient) CreateServiceToken(ctx context.Context, name, duration string) (*ServiceToken, error) {
+	return c.serviceTokenRequest(ctx, http.MethodPost, "/service_tokens", "create", ServiceTokenBody(name, duration))
+}
+
+func (c *httpClient) UpdateServiceToken(ctx context.Context, tokenID, name, duration string) error {
+	_, err := c.serviceTokenRequest(ctx, http.MethodPut, "/service_tokens/"+tokenID, "update", ServiceTokenBody(name, duration))
+	return err
+}
+
+func (c *httpClient) RotateServiceToken(ctx context.Context, tokenID string) (*ServiceToken, error) {
+	return c.serviceTokenRequest(ctx, http.MethodPost, "/service_tokens/"+tokenID+"/rotate", "rotate", nil)
+}
+
+func (c *httpClient) RefreshServiceToken(ctx context.Context, tokenID string) (*ServiceToken, error) {
+	return c.serviceTokenRequest(ctx, http.MethodPost, "/service_tokens/"+tokenID+"/refresh", "refresh", nil)
+}
+
+func (c *httpClient) DeleteServiceToken(ctx context.Context, tokenID string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.accountPath("/service_tokens/"+tokenID), nil)
+	if err != nil && !IsNotFound(err) {
+		return fmt.Errorf("delete service token: %w", err)
+	}
+	return nil
+}
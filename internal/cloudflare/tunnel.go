@@ -0,0 +1,170 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// Tunnel is a Cloudflare Tunnel.
+type Tunnel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// CNAMETarget returns the hostname DNS records point at to route through
+// the tunnel.
+func (t *Tunnel) CNAMETarget() string {
+	return t.ID + ".cfargotunnel.com"
+}
+
+// TunnelIngressRule routes requests for a hostname (and optionally a path
+// regex) to a service, e.g. "http://wiki.default:8080". A rule without
+// hostname and path is the catch-all that must come last.
+type TunnelIngressRule struct {
+	Hostname      string         `json:"hostname,omitempty"`
+	Path          string         `json:"path,omitempty"`
+	Service       string         `json:"service"`
+	OriginRequest map[string]any `json:"originRequest,omitempty"`
+}
+
+func (r TunnelIngressRule) catchAll() bool {
+	return r.Hostname == "" && r.Path == ""
+}
+
+// SameTunnelRules reports whether two rule lists are the same, in the same
+// order. Rules are compared as JSON, so origin request settings decoded
+// from the API compare equal to the ones they were built from.
+func SameTunnelRules(a, b []TunnelIngressRule) bool {
+	return slices.EqualFunc(a, b, func(a, b TunnelIngressRule) bool {
+		ja, errA := json.Marshal(a)
+		jb, errB := json.Marshal(b)
+		return errA == nil && errB == nil && bytes.Equal(ja, jb)
+	})
+}
+
+func (c *httpClient) tunnelPath(suffix string) string {
+	return fmt.Sprintf("/accounts/%s/cfd_tunnel%s", c.accountID, suffix)
+}
+
+func (c *httpClient) FindTunnelByName(ctx context.Context, name string) (*Tunnel, error) {
+	items, err := c.listAll(ctx, c.tunnelPath(""), url.Values{"name": {name}, "is_deleted": {"false"}})
+	if err != nil {
+		return nil, fmt.Errorf("list tunnels: %w", err)
+	}
+	for _, raw := range items {
+		var tunnel Tunnel
+		if err := json.Unmarshal(raw, &tunnel); err != nil {
+			return nil, fmt.Errorf("unmarshal tunnel: %w", err)
+		}
+		if tunnel.Name == name {
+			return &tunnel, nil
+		}
+	}
+	return nil, nil
+}
+
+// tunnelConfig is the remotely managed configuration of a tunnel. Fields
+// other than ingress are kept as they are.
+type tunnelConfig struct {
+	fields  map[string]json.RawMessage
+	ingress []TunnelIngressRule
+}
+
+func (c *httpClient) getTunnelConfig(ctx context.Context, tunnelID string) (*tunnelConfig, error) {
+	respBody, err := c.do(ctx, http.MethodGet, c.tunnelPath("/"+tunnelID+"/configurations"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("get tunnel configuration: %w", err)
+	}
+
+	var result struct {
+		Result struct {
+			Config map[string]json.RawMessage `json:"config"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal tunnel configuration: %w", err)
+	}
+	config := &tunnelConfig{fields: result.Result.Config}
+	if config.fields == nil {
+		config.fields = make(map[string]json.RawMessage)
+	}
+	if raw, ok := config.fields["ingress"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &config.ingress); err != nil {
+			return nil, fmt.Errorf("unmarshal tunnel ingress rules: %w", err)
+		}
+	}
+	return config, nil
+}
+
+func (c *httpClient) putTunnelConfig(ctx context.Context, tunnelID string, config *tunnelConfig) error {
+	ingress, err := json.Marshal(config.ingress)
+	if err != nil {
+		return fmt.Errorf("marshal tunnel ingress rules: %w", err)
+	}
+	config.fields["ingress"] = ingress
+
+	body := map[string]any{"config": config.fields}
+	if _, err := c.do(ctx, http.MethodPut, c.tunnelPath("/"+tunnelID+"/configurations"), body); err != nil {
+		return fmt.Errorf("update tunnel configuration: %w", err)
+	}
+	return nil
+}
+
+// hostRules returns the rules of hostname, in order.
+func (t *tunnelConfig) hostRules(hostname string) []TunnelIngressRule {
+	var rules []TunnelIngressRule
+	for _, rule := range t.ingress {
+		if rule.Hostname == hostname {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (c *httpClient) GetTunnelRoutes(ctx context.Context, tunnelID, hostname string) ([]TunnelIngressRule, error) {
+	config, err := c.getTunnelConfig(ctx, tunnelID)
+	if err != nil {
+		return nil, err
+	}
+	return config.hostRules(hostname), nil
+}
+
+func (c *httpClient) UpsertTunnelRoutes(ctx context.Context, tunnelID, hostname string, rules []TunnelIngressRule) (bool, error) {
+	config, err := c.getTunnelConfig(ctx, tunnelID)
+	if err != nil {
+		return false, err
+	}
+	if SameTunnelRules(config.hostRules(hostname), rules) {
+		return false, nil
+	}
+
+	config.ingress = slices.DeleteFunc(config.ingress, func(r TunnelIngressRule) bool { return r.Hostname == hostname })
+	catchAll := slices.IndexFunc(config.ingress, TunnelIngressRule.catchAll)
+	if catchAll < 0 {
+		config.ingress = append(append(config.ingress, rules...), TunnelIngressRule{Service: "http_status:404"})
+	} else {
+		config.ingress = slices.Insert(config.ingress, catchAll, rules...)
+	}
+	return true, c.putTunnelConfig(ctx, tunnelID, config)
+}
+
+func (c *httpClient) DeleteTunnelRoute(ctx context.Context, tunnelID, hostname string) error {
+	config, err := c.getTunnelConfig(ctx, tunnelID)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	n := len(config.ingress)
+	config.ingress = slices.DeleteFunc(config.ingress, func(r TunnelIngressRule) bool { return r.Hostname == hostname })
+	if len(config.ingress) == n {
+		return nil
+	}
+	return c.putTunnelConfig(ctx, tunnelID, config)
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// AccessGroupReconciler manages Cloudflare Access groups. It relies on the
+// GroupRefIndexField index being registered for SecuredApplications and
+// AccessPolicies by their controllers.
+type AccessGroupReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	Cloudflare cfclient.Client
+	Config     Config
+
+	// Recorder emits an Event on the AccessGroup for every change made in
+	// Cloudflare and for every failed reconcile.
+	Recorder events.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=access.twiechert.de,resources=accessgroups,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=access.twiechert.de,resources=accessgroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=access.twiechert.de,resources=accessgroups/finalizers,verbs=update
+
+func (r *AccessGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return r.accountObjectReconciler().reconcile(ctx, req, &accessv1alpha1.AccessGroup{})
+}
+
+func (r *AccessGroupReconciler) accountObjectReconciler() *accountObjectReconciler[*accessv1alpha1.AccessGroup, cfclient.AccessGroup] {
+	return &accountObjectReconciler[*accessv1alpha1.AccessGroup, cfclient.AccessGroup]{
+		Client:             r.Client,
+		Recorder:           r.Recorder,
+		Config:             r.Config,
+		kind:               "Access group",
+		eventKind:          "AccessGroup",
+		objectID:           func(group *accessv1alpha1.AccessGroup) *string { return &group.Status.GroupID },
+		observedGeneration: func(group *accessv1alpha1.AccessGroup) *int64 { return &group.Status.ObservedGeneration },
+		groupRefs:          groupGroupRefs,
+		users: func(ctx context.Context, group *accessv1alpha1.AccessGroup) ([]string, error) {
+			return groupUsers(ctx, r.Client, group.Name)
+		},
+		desired: builder.Group,
+		detect:  r.Cloudflare.DetectGroupDrift,
+		missing: func(drift *cfclient.Drift) bool { return drift.GroupMissing },
+		upsert:  r.Cloudflare.UpsertAccessGroup,
+		delete:  r.Cloudflare.DeleteAccessGroup,
+	}
+}
+
+func (r *AccessGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return setupAccountObjectController(mgr, &accessv1alpha1.AccessGroup{}, indexGroupGroupRefs, r.groupsUsingGroup, r)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// AccessPolicyReconciler manages reusable account-level Cloudflare Access
+// policies. It relies on the PolicyRefIndexField index registered by
+// SecuredApplicationReconciler.SetupWithManager.
+type AccessPolicyReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	Cloudflare cfclient.Client
+	Config     Config
+
+	// Recorder emits an Event on the AccessPolicy for every change made in
+	// Cloudflare and for every failed reconcile.
+	Recorder events.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=access.twiechert.de,resources=accesspolicies,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=access.twiechert.de,resources=accesspolicies/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=access.twiechert.de,resources=accesspolicies/finalizers,verbs=update
+
+func (r *AccessPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return r.accountObjectReconciler().reconcile(ctx, req, &accessv1alpha1.AccessPolicy{})
+}
+
+func (r *AccessPolicyReconciler) accountObjectReconciler() *accountObjectReconciler[*accessv1alpha1.AccessPolicy, cfclient.AccessPolicy] {
+	return &accountObjectReconciler[*accessv1alpha1.AccessPolicy, cfclient.AccessPolicy]{
+		Client:             r.Client,
+		Recorder:           r.Recorder,
+		Config:             r.Config,
+		kind:               "reusable Access policy",
+		eventKind:          "ReusablePolicy",
+		objectID:           func(policy *accessv1alpha1.AccessPolicy) *string { return &policy.Status.PolicyID },
+		observedGeneration: func(policy *accessv1alpha1.AccessPolicy) *int64 { return &policy.Status.ObservedGeneration },
+		groupRefs:          policyGroupRefs,
+		users: func(ctx context.Context, policy *accessv1alpha1.AccessPolicy) ([]string, error) {
+			apps, err := policyUsers(ctx, r.Client, policy.Name)
+			if err != nil {
+				return nil, err
+			}
+			users := make([]string, len(apps))
+			for i, app := range apps {
+				users[i] = fmt.Sprintf("SecuredApplication %s/%s", app.Namespace, app.Name)
+			}
+			return users, nil
+		},
+		desired: builder.ReusablePolicy,
+		detect:  r.Cloudflare.DetectReusablePolicyDrift,
+		missing: func(drift *cfclient.Drift) bool { return drift.PolicyMissing },
+		upsert:  r.Cloudflare.UpsertReusablePolicy,
+		delete:  r.Cloudflare.DeleteReusablePolicy,
+	}
+}
+
+func (r *AccessPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return setupAccountObjectController(mgr, &accessv1alpha1.AccessPolicy{}, indexPolicyGroupRefs, r.policiesUsingGroup, r)
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// accountObjectReconciler reconciles a cluster-scoped resource that manages
+// one account-level Cloudflare object, i.e. an AccessPolicy or an
+// AccessGroup. T is the resource and D the desired Cloudflare object; the
+// function fields describe how the kinds differ.
+type accountObjectReconciler[T statusObject, D any] struct {
+	client.Client
+	Recorder events.EventRecorder
+	Config   Config
+
+	// kind names the Cloudflare object in messages, e.g. "Access group",
+	// and eventKind in Event reasons, e.g. "AccessGroup".
+	kind      string
+	eventKind string
+
+	// objectID and observedGeneration point into the status of obj.
+	objectID           func(obj T) *string
+	observedGeneration func(obj T) *int64
+
+	// groupRefs returns the AccessGroups the rules of obj reference.
+	groupRefs func(obj T) []string
+
+	// users describes every object still referencing obj, e.g.
+	// "SecuredApplication default/wiki". obj is not deleted while any is left.
+	users func(ctx context.Context, obj T) ([]string, error)
+
+	desired func(obj T, refs builder.Refs) D
+	detect  func(ctx context.Context, id string, desired D) (*cfclient.Drift, error)
+	missing func(drift *cfclient.Drift) bool
+	upsert  func(ctx context.Context, id string, desired D) (string, error)
+	delete  func(ctx context.Context, id string) error
+}
+
+func (r *accountObjectReconciler[T, D]) reconcile(ctx context.Context, req ctrl.Request, obj T) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	status := statusWriter{client: r.Client, recorder: r.Recorder, resync: r.Config.ResyncInterval}
+
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	id := r.objectID(obj)
+
+	// Handle deletion. Deleting the Cloudflare object would silently change
+	// the access of the objects using it, so wait until none does.
+	if !obj.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(obj, finalizerName) {
+			return ctrl.Result{}, nil
+		}
+		users, err := r.users(ctx, obj)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(users) > 0 {
+			logger.Info("waiting for "+r.kind+" to be unreferenced", "users", users)
+			return status.setReady(ctx, obj, metav1.ConditionFalse, "InUse", fmt.Sprintf("still referenced by %s", strings.Join(users, ", ")))
+		}
+		if *id != "" && !r.dryRun(obj) {
+			logger.Info("deleting "+r.kind, "id", *id)
+			if err := r.delete(ctx, *id); err != nil {
+				r.Recorder.Eventf(obj, nil, corev1.EventTypeWarning, "DeleteFailed", "Delete", "Failed to delete %s %s: %v", r.kind, *id, err)
+				return ctrl.Result{RequeueAfter: deleteRequeue(err)}, nil
+			}
+			r.Recorder.Eventf(obj, nil, corev1.EventTypeNormal, "Deleted"+r.eventKind, "Delete", "Deleted %s %s", r.kind, *id)
+		}
+		controllerutil.RemoveFinalizer(obj, finalizerName)
+		return ctrl.Result{}, r.Update(ctx, obj)
+	}
+
+	refs, err := resolveRefs(ctx, r.Client, r.Config.CloudflareIdPID, r.groupRefs(obj))
+	if err != nil {
+		return status.fail(ctx, obj, "GroupRefNotReady", err)
+	}
+	desired := r.desired(obj, refs)
+	drift, err := r.detect(ctx, *id, desired)
+	if err != nil {
+		return status.fail(ctx, obj, "CloudflareLookupFailed", err)
+	}
+
+	if r.dryRun(obj) {
+		msg := "Dry run: no changes needed"
+		switch {
+		case r.missing(drift):
+			msg = fmt.Sprintf("Dry run: would create %s %q", r.kind, obj.GetName())
+		case drift.Drifted():
+			msg = fmt.Sprintf("Dry run: would update %s: %s", r.kind, strings.Join(drift.Changes, "; "))
+		}
+		return status.setReady(ctx, obj, metav1.ConditionUnknown, "DryRun", msg)
+	}
+
+	if !controllerutil.ContainsFinalizer(obj, finalizerName) {
+		controllerutil.AddFinalizer(obj, finalizerName)
+		if err := r.Update(ctx, obj); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if drift.Drifted() {
+		existingID := *id
+		repairing := existingID != "" && *r.observedGeneration(obj) == obj.GetGeneration()
+		if r.missing(drift) {
+			existingID = ""
+		}
+		upsertedID, err := r.upsert(ctx, existingID, desired)
+		if err != nil {
+			return status.fail(ctx, obj, r.eventKind+"Failed", err)
+		}
+		if repairing {
+			r.Recorder.Eventf(obj, nil, corev1.EventTypeWarning, "DriftRepaired", "Update", "Reverted changes made in Cloudflare: %s", strings.Join(drift.Changes, "; "))
+		}
+		if upsertedID != existingID {
+			logger.Info("created "+r.kind, "id", upsertedID)
+			r.Recorder.Eventf(obj, nil, corev1.EventTypeNormal, "Created"+r.eventKind, "Create", "Created %s %s", r.kind, upsertedID)
+		} else {
+			r.Recorder.Eventf(obj, nil, corev1.EventTypeNormal, "Updated"+r.eventKind, "Update", "Updated %s %s", r.kind, upsertedID)
+		}
+		*id = upsertedID
+	}
+
+	*r.observedGeneration(obj) = obj.GetGeneration()
+	return status.setReady(ctx, obj, metav1.ConditionTrue, "Reconciled", fmt.Sprintf("Cloudflare %s %s is up to date", r.kind, *id))
+}
+
+// dryRun reports whether obj must not be changed in Cloudflare.
+func (r *accountObjectReconciler[T, D]) dryRun(obj T) bool {
+	return r.Config.DryRun || obj.GetAnnotations()[dryRunAnnotation] == "true"
+}
+
+// setupAccountObjectController registers the GroupRefIndexField index for
+// obj and a controller that also reconciles the objects referencing an
+// AccessGroup whenever it changes.
+func setupAccountObjectController(mgr ctrl.Manager, obj client.Object, indexGroupRefs client.IndexerFunc, usingGroup handler.MapFunc, r reconcile.Reconciler) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), obj, GroupRefIndexField, indexGroupRefs); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(obj).
+		Watches(&accessv1alpha1.AccessGroup{}, handler.EnqueueRequestsFromMapFunc(usingGroup)).
+		Complete(r)
+}
@@ -0,0 +1,515 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/zitadel"
+)
+
+const (
+	// Setting this annotation to "true" puts a single SecuredApplication in
+	// dry-run mode, as --dry-run does for all of them.
+	dryRunAnnotation = "access.twiechert.de/dry-run"
+
+	// maxEventNote is the length limit of an events.k8s.io Event note.
+	maxEventNote = 1024
+)
+
+// dryRun reports whether app must not be changed in Zitadel, Cloudflare or
+// Kubernetes.
+func (r *SecuredApplicationReconciler) dryRun(app *accessv1alpha1.SecuredApplication) bool {
+	return r.Config.DryRun || app.Annotations[dryRunAnnotation] == "true"
+}
+
+// reconcileDryRun computes the plan for app and records it in status.plan
+// and an Event instead of applying it.
+func (r *SecuredApplicationReconciler) reconcileDryRun(ctx context.Context, app *accessv1alpha1.SecuredApplication) (ctrl.Result, error) {
+	plan, err := r.plan(ctx, app)
+	if err != nil {
+		return r.fail(ctx, app, accessv1alpha1.ConditionReady, "PlanFailed", err)
+	}
+
+	app.Status.Plan = plan
+	message := "Dry run: no changes needed"
+	if len(plan) > 0 {
+		message = fmt.Sprintf("Dry run: %d changes planned, see status.plan", len(plan))
+		note := strings.Join(plan, "; ")
+		if len(note) > maxEventNote {
+			note = note[:maxEventNote-3] + "..."
+		}
+		r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "Planned", "Plan", "%s", note)
+	}
+
+	result, err := r.setCondition(ctx, app, metav1.ConditionUnknown, "DryRun", message)
+	if err != nil {
+		return result, err
+	}
+	return ctrl.Result{RequeueAfter: r.Config.ResyncInterval}, nil
+}
+
+// plan returns the changes a reconcile of app would make, in order, using
+// only read-only calls. Kubernetes objects are compared via server-side dry
+// run. Entries starting with "blocked:" stop the reconcile at that point.
+func (r *SecuredApplicationReconciler) plan(ctx context.Context, app *accessv1alpha1.SecuredApplication) ([]string, error) {
+	var plan []string
+
+	// Zitadel project and roles.
+	project, err := r.Zitadel.GetProjectByName(ctx, app.Spec.Access.Project)
+	if err != nil {
+		return nil, fmt.Errorf("look up Zitadel project: %w", err)
+	}
+	if project == nil {
+		return append(plan, fmt.Sprintf("blocked: Zitadel project %q not found", app.Spec.Access.Project)), nil
+	}
+	if requested := requestedRoles(app); len(requested) > 0 {
+		roles, err := r.Zitadel.ListProjectRoles(ctx, project.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list Zitadel project roles: %w", err)
+		}
+		roleSet := make(map[string]bool, len(roles))
+		for _, role := range roles {
+			roleSet[role.Key] = true
+		}
+		for _, role := range requested {
+			if !roleSet[role] {
+				return append(plan, fmt.Sprintf("blocked: role %q does not exist in Zitadel project %q", role, app.Spec.Access.Project)), nil
+			}
+		}
+	}
+	if !hasAccessPolicies(app) {
+		return append(plan, "blocked: at least one of roles, claims, include, policies, policyRefs or serviceTokens must be specified"), nil
+	}
+
+	// Zitadel OIDC app and credentials.
+	config := builder.ZitadelAppConfig(app)
+	redirects := strings.Join(config.RedirectURIs, ", ")
+	clientID := app.Status.ClientID
+	newClientSecret := false
+	appID := app.Status.ZitadelAppID
+	if appID != "" {
+		live, err := r.Zitadel.GetAppConfig(ctx, project.ID, appID)
+		if err != nil {
+			return nil, fmt.Errorf("get Zitadel app: %w", err)
+		}
+		if live != nil {
+			for _, change := range zitadel.DiffAppConfig(*live, config) {
+				plan = append(plan, fmt.Sprintf("update Zitadel OIDC app %s: %s", appID, change))
+			}
+		} else {
+			plan = append(plan, fmt.Sprintf("Zitadel OIDC app %s no longer exists", appID))
+			appID = ""
+		}
+	}
+	if appID == "" {
+		existing, err := r.Zitadel.GetAppByName(ctx, project.ID, app.Name)
+		if err != nil {
+			return nil, fmt.Errorf("look up Zitadel app: %w", err)
+		}
+		if existing != nil {
+			plan = append(plan, fmt.Sprintf("adopt Zitadel OIDC app %s", existing.ID))
+			live, err := r.Zitadel.GetAppConfig(ctx, project.ID, existing.ID)
+			if err != nil {
+				return nil, fmt.Errorf("get Zitadel app: %w", err)
+			}
+			if live != nil {
+				for _, change := range zitadel.DiffAppConfig(*live, config) {
+					plan = append(plan, fmt.Sprintf("update Zitadel OIDC app %s: %s", existing.ID, change))
+				}
+			}
+			clientID = existing.ClientID
+		} else {
+			plan = append(plan, fmt.Sprintf("create Zitadel OIDC app %q in project %q (redirect URIs %s)", app.Name, app.Spec.Access.Project, redirects))
+			newClientSecret = true
+		}
+	}
+	secretName := credentialSecretName(app)
+	switch {
+	case newClientSecret:
+		plan = append(plan, fmt.Sprintf("write client credentials to Secret %s", secretName))
+	case secretRotationDue(app, time.Now()):
+		plan = append(plan, fmt.Sprintf("rotate the client secret and write Secret %s", secretName))
+	case usesClientSecret(app):
+		missing, err := r.credentialSecretMissing(ctx, app, clientID)
+		if err != nil {
+			return nil, fmt.Errorf("get credential Secret: %w", err)
+		}
+		if missing {
+			plan = append(plan, fmt.Sprintf("regenerate the client secret and write Secret %s", secretName))
+		}
+	}
+
+	// Service tokens.
+	tokenPlan, err := r.planServiceTokens(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	plan = append(plan, tokenPlan...)
+
+	// Cloudflare Access Application and policy.
+	reusableIDs, err := r.resolvePolicyRefs(ctx, app)
+	if err != nil {
+		return append(plan, "blocked: "+err.Error()), nil
+	}
+	refs, err := resolveRefs(ctx, r.Client, r.Config.CloudflareIdPID, appGroupRefs(app))
+	if err != nil {
+		return append(plan, "blocked: "+err.Error()), nil
+	}
+	refs.ServiceTokenIDs = serviceTokenIDs(app)
+	cfPlan, err := r.planAccessApp(ctx, app, refs, reusableIDs)
+	if err != nil {
+		return nil, err
+	}
+	plan = append(plan, cfPlan...)
+	for _, name := range slices.Sorted(maps.Keys(app.Status.ServiceTokens)) {
+		if !slices.ContainsFunc(app.Spec.Access.ServiceTokens, func(t accessv1alpha1.ServiceTokenConfig) bool { return t.Name == name }) {
+			plan = append(plan, fmt.Sprintf("delete service token %s (%s)", app.Status.ServiceTokens[name].ID, name))
+		}
+	}
+
+	// Bypass Access Applications.
+	for _, path := range slices.Sorted(maps.Keys(app.Status.BypassApplicationIDs)) {
+		if !slices.Contains(app.Spec.Access.BypassPaths, path) {
+			plan = append(plan, fmt.Sprintf("delete bypass Access Application %s for %s", app.Status.BypassApplicationIDs[path], path))
+		}
+	}
+	for _, bypass := range builder.BypassApps(app) {
+		if existingID, ok := app.Status.BypassApplicationIDs[bypass.Path]; ok {
+			drift, err := r.Cloudflare.DetectBypassDrift(ctx, existingID, bypass.Name, bypass.Domains)
+			if err != nil {
+				return nil, fmt.Errorf("detect drift of bypass app for %q: %w", bypass.Path, err)
+			}
+			if !drift.AppMissing {
+				for _, change := range drift.Changes {
+					plan = append(plan, fmt.Sprintf("update bypass Access Application %s: %s", existingID, change))
+				}
+				continue
+			}
+			plan = append(plan, fmt.Sprintf("bypass Access Application %s no longer exists", existingID))
+		}
+		plan = append(plan, fmt.Sprintf("create bypass Access Application for %s", strings.Join(bypass.Domains, ", ")))
+	}
+
+	// Path Access Applications.
+	pathPlan, err := r.planPathApps(ctx, app, refs)
+	if err != nil {
+		return nil, err
+	}
+	plan = append(plan, pathPlan...)
+
+	// Ingresses and HTTPRoutes.
+	routePlan, err := r.planRoutes(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	return append(plan, routePlan...), nil
+}
+
+func (r *SecuredApplicationReconciler) planAccessApp(ctx context.Context, app *accessv1alpha1.SecuredApplication, refs builder.Refs, reusableIDs map[string]string) ([]string, error) {
+	var plan []string
+	desired := r.desiredAccessApp(app, refs, reusableIDs)
+	accessAppID := app.Status.AccessApplicationID
+	policyID := app.Status.AccessPolicyID
+
+	var drift *cfclient.Drift
+	if accessAppID != "" {
+		var err error
+		drift, err = r.Cloudflare.DetectDrift(ctx, accessAppID, policyID, desired)
+		if err != nil {
+			return nil, fmt.Errorf("detect drift: %w", err)
+		}
+		if drift.AppMissing {
+			plan = append(plan, fmt.Sprintf("Access Application %s no longer exists", accessAppID))
+			accessAppID = ""
+			drift = nil
+		}
+	}
+
+	if accessAppID == "" {
+		existing, err := r.Cloudflare.FindAccessAppByDomain(ctx, desired.Domains[0])
+		if err != nil {
+			return nil, fmt.Errorf("find Access Application: %w", err)
+		}
+		if existing == nil {
+			plan = append(plan, fmt.Sprintf("create Access Application %q for %s", desired.Name, strings.Join(desired.Domains, ", ")))
+			if builder.HasAllowPolicy(app) {
+				plan = append(plan, "create Access policy with "+describePolicy(desired.Policy))
+			}
+			for _, policy := range builder.AccessPolicies(app, refs) {
+				plan = append(plan, fmt.Sprintf("create Access policy %q (%s)", policy.Name, policy.Decision))
+			}
+			if len(desired.ReusablePolicies) > 0 {
+				plan = append(plan, fmt.Sprintf("attach reusable Access policies %v", app.Spec.Access.PolicyRefs))
+			}
+			return plan, nil
+		}
+		plan = append(plan, fmt.Sprintf("adopt Access Application %s for %s", existing.ID, desired.Domains[0]))
+		accessAppID = existing.ID
+		drift, err = r.Cloudflare.DetectDrift(ctx, accessAppID, "", desired)
+		if err != nil {
+			return nil, fmt.Errorf("detect drift: %w", err)
+		}
+	}
+
+	for _, change := range drift.Changes {
+		plan = append(plan, fmt.Sprintf("update Access Application %s: %s", accessAppID, change))
+	}
+	switch {
+	case !builder.HasAllowPolicy(app) && policyID != "":
+		plan = append(plan, fmt.Sprintf("delete Access policy %s", policyID))
+	case drift.PolicyMissing:
+		plan = append(plan, "create Access policy with "+describePolicy(desired.Policy))
+	}
+
+	policyPlan, err := r.planAccessPolicies(ctx, app, refs, accessAppID)
+	if err != nil {
+		return nil, err
+	}
+	return append(plan, policyPlan...), nil
+}
+
+// planServiceTokens mirrors reconcileServiceTokens, judging expiry from the
+// status.
+func (r *SecuredApplicationReconciler) planServiceTokens(ctx context.Context, app *accessv1alpha1.SecuredApplication) ([]string, error) {
+	var plan []string
+	now := time.Now()
+	for i := range app.Spec.Access.ServiceTokens {
+		token := &app.Spec.Access.ServiceTokens[i]
+		secretName := serviceTokenSecretName(app, token)
+		status, ok := app.Status.ServiceTokens[token.Name]
+		if !ok {
+			name, _ := builder.ServiceToken(app, token)
+			plan = append(plan, fmt.Sprintf("create service token %q and write Secret %s", name, secretName))
+			continue
+		}
+		if status.ExpiresAt != nil && !now.Before(serviceTokenRenewal(app, token, status.ExpiresAt.Time)) {
+			plan = append(plan, fmt.Sprintf("refresh and rotate service token %s and write Secret %s", status.ID, secretName))
+			continue
+		}
+		missing, err := r.serviceTokenSecretMissing(ctx, app, secretName, status.ClientID)
+		if err != nil {
+			return nil, fmt.Errorf("get service token Secret: %w", err)
+		}
+		if missing {
+			plan = append(plan, fmt.Sprintf("rotate service token %s and write Secret %s", status.ID, secretName))
+		}
+	}
+	return plan, nil
+}
+
+// planAccessPolicies mirrors reconcileAccessPolicies.
+func (r *SecuredApplicationReconciler) planAccessPolicies(ctx context.Context, app *accessv1alpha1.SecuredApplication, refs builder.Refs, accessAppID string) ([]string, error) {
+	var plan []string
+	desired := builder.AccessPolicies(app, refs)
+	wanted := make(map[string]bool, len(desired))
+	for _, policy := range desired {
+		wanted[policy.Name] = true
+	}
+	for _, name := range slices.Sorted(maps.Keys(app.Status.AccessPolicyIDs)) {
+		if !wanted[name] {
+			plan = append(plan, fmt.Sprintf("delete Access policy %s (%s)", app.Status.AccessPolicyIDs[name], name))
+		}
+	}
+	for _, policy := range desired {
+		policyID := app.Status.AccessPolicyIDs[policy.Name]
+		drift, err := r.Cloudflare.DetectPolicyDrift(ctx, accessAppID, policyID, policy)
+		if err != nil {
+			return nil, fmt.Errorf("detect drift of policy %q: %w", policy.Name, err)
+		}
+		if drift.PolicyMissing {
+			plan = append(plan, fmt.Sprintf("create Access policy %q (%s)", policy.Name, policy.Decision))
+			continue
+		}
+		for _, change := range drift.Changes {
+			plan = append(plan, fmt.Sprintf("update Access policy %s (%s): %s", policyID, policy.Name, change))
+		}
+	}
+	return plan, nil
+}
+
+// planRoutes mirrors reconcileRoutes against a dry-run client, so that
+// creates and updates are validated by the API server but not persisted.
+func (r *SecuredApplicationReconciler) planRoutes(ctx context.Context, app *accessv1alpha1.SecuredApplication) ([]string, error) {
+	dry := *r
+	dry.Client = client.NewDryRunClient(r.Client)
+
+	var plan []string
+	record := func(kind, name string, op controllerutil.OperationResult, err error) error {
+		if err != nil {
+			return fmt.Errorf("dry-run %s %s: %w", kind, name, err)
+		}
+		switch op {
+		case controllerutil.OperationResultCreated:
+			plan = append(plan, fmt.Sprintf("create %s %s", kind, name))
+		case controllerutil.OperationResultUpdated:
+			plan = append(plan, fmt.Sprintf("update %s %s", kind, name))
+		}
+		return nil
+	}
+	stale := func(kind string, obj client.Object) error {
+		owned, err := r.ownedObjectExists(ctx, app, obj)
+		if err != nil {
+			return fmt.Errorf("get %s %s: %w", kind, obj.GetName(), err)
+		}
+		if owned {
+			plan = append(plan, fmt.Sprintf("delete %s %s", kind, obj.GetName()))
+		}
+		return nil
+	}
+	wantOIDC := app.Spec.NativeOIDC != nil && app.Spec.NativeOIDC.Ingress != nil
+
+	if routingMode(app) == routingModeHTTPRoute {
+		if !r.Config.EnableGatewayAPI {
+			return append(plan, "blocked: routing mode HTTPRoute requires the operator to run with --enable-gateway-api"), nil
+		}
+		if app.Spec.Routing.Gateway == nil {
+			return append(plan, "blocked: routing.gateway is required in HTTPRoute mode"), nil
+		}
+
+		tunnel := builder.TunnelHTTPRoute(app)
+		op, err := dry.reconcileHTTPRoute(ctx, app, tunnel)
+		if err := record("HTTPRoute", tunnel.GetName(), op, err); err != nil {
+			return nil, err
+		}
+		if wantOIDC {
+			oidc := builder.OIDCHTTPRoute(app)
+			op, err := dry.reconcileHTTPRoute(ctx, app, oidc)
+			if err := record("HTTPRoute", oidc.GetName(), op, err); err != nil {
+				return nil, err
+			}
+		} else if err := stale("HTTPRoute", newHTTPRoute(app.Name+"-oidc", app.Namespace)); err != nil {
+			return nil, err
+		}
+		for _, name := range []string{app.Name, app.Name + "-oidc"} {
+			ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: app.Namespace}}
+			if err := stale("Ingress", ingress); err != nil {
+				return nil, err
+			}
+		}
+		return append(plan, planStaleTunnelRoutes(app, nil)...), nil
+	}
+
+	if wantOIDC && app.Spec.NativeOIDC.Ingress.ClassName == "" {
+		return append(plan, fmt.Sprintf("blocked: nativeOIDC.ingress.className is required in %s routing mode", routingMode(app))), nil
+	}
+	if routingMode(app) == routingModeTunnel {
+		if r.tunnelName(app) == "" {
+			return append(plan, "blocked: routing mode Tunnel requires routing.tunnelName or the operator's --cloudflare-tunnel-name"), nil
+		}
+		tunnel, err := r.findTunnel(ctx, app)
+		if err != nil {
+			return append(plan, "blocked: "+err.Error()), nil
+		}
+		hostPlan, err := r.planTunnelHosts(ctx, app, tunnel)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, hostPlan...)
+		plan = append(plan, planStaleTunnelRoutes(app, tunnel)...)
+		ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: app.Namespace}}
+		if err := stale("Ingress", ingress); err != nil {
+			return nil, err
+		}
+	} else {
+		op, err := dry.reconcileIngress(ctx, app)
+		if err := record("Ingress", app.Name, op, err); err != nil {
+			return nil, err
+		}
+		plan = append(plan, planStaleTunnelRoutes(app, nil)...)
+	}
+	if wantOIDC {
+		op, err := dry.reconcileOIDCIngress(ctx, app)
+		if err := record("Ingress", app.Name+"-oidc", op, err); err != nil {
+			return nil, err
+		}
+	} else {
+		ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: app.Name + "-oidc", Namespace: app.Namespace}}
+		if err := stale("Ingress", ingress); err != nil {
+			return nil, err
+		}
+	}
+	if r.Config.EnableGatewayAPI {
+		for _, name := range []string{app.Name, app.Name + "-oidc"} {
+			if err := stale("HTTPRoute", newHTTPRoute(name, app.Namespace)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return plan, nil
+}
+
+// planTunnelHosts mirrors the per-host part of reconcileTunnelRoutes,
+// comparing the rules and DNS record of each host against the live ones.
+func (r *SecuredApplicationReconciler) planTunnelHosts(ctx context.Context, app *accessv1alpha1.SecuredApplication, tunnel *cfclient.Tunnel) ([]string, error) {
+	var plan []string
+	rules := builder.TunnelIngressRules(app)
+	for _, host := range builder.Hosts(app) {
+		hostRules := slices.DeleteFunc(slices.Clone(rules), func(rule cfclient.TunnelIngressRule) bool { return rule.Hostname != host })
+		live, err := r.Cloudflare.GetTunnelRoutes(ctx, tunnel.ID, host)
+		if err != nil {
+			return nil, fmt.Errorf("get routes of %s in tunnel %s: %w", host, tunnel.Name, err)
+		}
+		if !cfclient.SameTunnelRules(live, hostRules) {
+			for _, rule := range hostRules {
+				if rule.Path != "" {
+					plan = append(plan, fmt.Sprintf("route %s (path %s) through tunnel %s to %s", host, rule.Path, tunnel.Name, rule.Service))
+				} else {
+					plan = append(plan, fmt.Sprintf("route %s through tunnel %s to %s", host, tunnel.Name, rule.Service))
+				}
+			}
+		}
+
+		zoneID, err := r.Cloudflare.FindZoneID(ctx, host)
+		if err != nil {
+			return append(plan, "blocked: "+err.Error()), nil
+		}
+		record, err := r.Cloudflare.FindDNSRecord(ctx, zoneID, host)
+		if err != nil {
+			return nil, fmt.Errorf("get DNS record %s: %w", host, err)
+		}
+		switch {
+		case record == nil:
+			plan = append(plan, fmt.Sprintf("create DNS record %s pointing at %s", host, tunnel.CNAMETarget()))
+		case record.Type != "CNAME" || !record.Managed():
+			plan = append(plan, "blocked: "+(&cfclient.DNSConflictError{Name: host, Type: record.Type}).Error())
+		case record.Content != tunnel.CNAMETarget() || !record.Proxied:
+			plan = append(plan, fmt.Sprintf("point DNS record %s at %s (proxied) instead of %s", host, tunnel.CNAMETarget(), record.Content))
+		}
+	}
+	return plan, nil
+}
+
+// planStaleTunnelRoutes lists the routes in status that reconcileTunnelRoutes
+// or deleteTunnelRoutes would remove. tunnel is nil outside Tunnel mode.
+func planStaleTunnelRoutes(app *accessv1alpha1.SecuredApplication, tunnel *cfclient.Tunnel) []string {
+	var plan []string
+	hosts := builder.Hosts(app)
+	for _, route := range app.Status.TunnelRoutes {
+		if tunnel == nil || !slices.Contains(hosts, route.Hostname) {
+			plan = append(plan, fmt.Sprintf("remove tunnel route and DNS record for %s", route.Hostname))
+		} else if route.TunnelID != tunnel.ID {
+			plan = append(plan, fmt.Sprintf("remove route for %s from tunnel %s", route.Hostname, route.TunnelID))
+		}
+	}
+	return plan
+}
+
+// describePolicy summarizes the rule lists of a policy, e.g.
+// "2 include, 1 require and 0 exclude rules".
+func describePolicy(policy cfclient.PolicyRules) string {
+	return fmt.Sprintf("%d include, %d require and %d exclude rules", len(policy.Include), len(policy.Require), len(policy.Exclude))
+}
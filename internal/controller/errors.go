@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/zitadel"
+)
+
+const (
+	// shortRequeue is used for rate limits and server errors without a Retry-After.
+	shortRequeue = 10 * time.Second
+
+	// longRequeue is used for failures that need outside intervention, such
+	// as expired credentials or a network outage.
+	longRequeue = 1 * time.Minute
+)
+
+// retryPolicy describes how to react to a failed external call.
+type retryPolicy struct {
+	// requeueAfter is the delay before the next attempt.
+	requeueAfter time.Duration
+
+	// terminal is set when retrying the same spec cannot succeed, e.g. the
+	// API rejected the request as invalid. The object is only retried on the
+	// periodic resync, which keeps drift repair running, or when it changes.
+	terminal bool
+}
+
+// classifyError maps a Cloudflare or Zitadel API error to a retry policy:
+// rate limits and server errors get a short requeue (honoring Retry-After),
+// requests the API rejected as invalid are terminal, and everything else
+// (authentication failures, network errors) gets a long requeue. A resource
+// not found was usually deleted outside the operator; it gets a short
+// requeue, and the next attempt notices it is gone and recreates it. A DNS
+// record the operator does not own is terminal until someone removes it.
+func classifyError(err error) retryPolicy {
+	var (
+		statusCode int
+		retryAfter time.Duration
+	)
+	var cfErr *cfclient.APIError
+	var zErr *zitadel.APIError
+	var dnsConflict *cfclient.DNSConflictError
+	switch {
+	case errors.As(err, &dnsConflict):
+		return retryPolicy{terminal: true}
+	case errors.As(err, &cfErr):
+		statusCode, retryAfter = cfErr.StatusCode, cfErr.RetryAfter
+	case errors.As(err, &zErr):
+		statusCode, retryAfter = zErr.StatusCode, zErr.RetryAfter
+	default:
+		return retryPolicy{requeueAfter: longRequeue}
+	}
+
+	switch {
+	case statusCode == http.StatusTooManyRequests || statusCode >= 500,
+		statusCode == http.StatusNotFound:
+		if retryAfter > 0 {
+			return retryPolicy{requeueAfter: retryAfter}
+		}
+		return retryPolicy{requeueAfter: shortRequeue}
+	case statusCode == http.StatusBadRequest,
+		statusCode == http.StatusConflict,
+		statusCode == http.StatusUnprocessableEntity:
+		return retryPolicy{terminal: true}
+	default:
+		return retryPolicy{requeueAfter: longRequeue}
+	}
+}
+
+// fail records a failed reconcile step on condType and the Ready condition
+// and requeues according to classifyError, see statusWriter.fail.
+func (r *SecuredApplicationReconciler) fail(ctx context.Context, app *accessv1alpha1.SecuredApplication, condType, reason string, err error) (ctrl.Result, error) {
+	markCondition(app, condType, metav1.ConditionFalse, reason, err.Error())
+	app.Status.Ready = false
+	return r.statusWriter().fail(ctx, app, reason, err)
+}
+
+// deleteRequeue returns the delay before retrying a failed cleanup. Cleanup
+// is never terminal since the finalizer must eventually be removed.
+func deleteRequeue(err error) time.Duration {
+	if policy := classifyError(err); !policy.terminal {
+		return policy.requeueAfter
+	}
+	return longRequeue
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+)
+
+// GroupRefIndexField indexes SecuredApplications, AccessPolicies and
+// AccessGroups by the AccessGroups their rules reference. Each controller
+// registers it for its own type.
+const GroupRefIndexField = "groupRefs"
+
+// groupRefs returns the AccessGroup names referenced by rules, without
+// duplicates and in first-use order.
+func groupRefs(lists ...[]accessv1alpha1.AccessRule) []string {
+	var names []string
+	for _, rules := range lists {
+		for _, rule := range rules {
+			if rule.GroupRef != "" && !slices.Contains(names, rule.GroupRef) {
+				names = append(names, rule.GroupRef)
+			}
+		}
+	}
+	return names
+}
+
+func appGroupRefs(app *accessv1alpha1.SecuredApplication) []string {
+	access := app.Spec.Access
+	lists := [][]accessv1alpha1.AccessRule{access.Include, access.Require, access.Exclude}
+	for _, policy := range access.Policies {
+		lists = append(lists, policy.Include, policy.Require, policy.Exclude)
+	}
+	for _, rule := range access.PathRules {
+		lists = append(lists, rule.Include, rule.Require, rule.Exclude)
+	}
+	return groupRefs(lists...)
+}
+
+func policyGroupRefs(policy *accessv1alpha1.AccessPolicy) []string {
+	return groupRefs(policy.Spec.Include, policy.Spec.Require, policy.Spec.Exclude)
+}
+
+func groupGroupRefs(group *accessv1alpha1.AccessGroup) []string {
+	return groupRefs(group.Spec.Include, group.Spec.Require, group.Spec.Exclude)
+}
+
+func indexAppGroupRefs(obj client.Object) []string {
+	return appGroupRefs(obj.(*accessv1alpha1.SecuredApplication))
+}
+
+func indexPolicyGroupRefs(obj client.Object) []string {
+	return policyGroupRefs(obj.(*accessv1alpha1.AccessPolicy))
+}
+
+func indexGroupGroupRefs(obj client.Object) []string {
+	return groupGroupRefs(obj.(*accessv1alpha1.AccessGroup))
+}
+
+// groupUsers describes every object whose rules reference the named
+// AccessGroup, e.g. "SecuredApplication default/wiki".
+func groupUsers(ctx context.Context, c client.Reader, name string) ([]string, error) {
+	var users []string
+
+	var apps accessv1alpha1.SecuredApplicationList
+	if err := c.List(ctx, &apps, client.MatchingFields{GroupRefIndexField: name}); err != nil {
+		return nil, fmt.Errorf("list SecuredApplications using AccessGroup %s: %w", name, err)
+	}
+	for _, app := range apps.Items {
+		users = append(users, fmt.Sprintf("SecuredApplication %s/%s", app.Namespace, app.Name))
+	}
+
+	var policies accessv1alpha1.AccessPolicyList
+	if err := c.List(ctx, &policies, client.MatchingFields{GroupRefIndexField: name}); err != nil {
+		return nil, fmt.Errorf("list AccessPolicies using AccessGroup %s: %w", name, err)
+	}
+	for _, policy := range policies.Items {
+		users = append(users, "AccessPolicy "+policy.Name)
+	}
+
+	var groups accessv1alpha1.AccessGroupList
+	if err := c.List(ctx, &groups, client.MatchingFields{GroupRefIndexField: name}); err != nil {
+		return nil, fmt.Errorf("list AccessGroups using AccessGroup %s: %w", name, err)
+	}
+	for _, group := range groups.Items {
+		if group.Name != name {
+			users = append(users, "AccessGroup "+group.Name)
+		}
+	}
+	return users, nil
+}
+
+// appsUsingGroup enqueues the SecuredApplications that reference obj, so
+// that they pick up its group ID once it is created or recreated.
+func (r *SecuredApplicationReconciler) appsUsingGroup(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list accessv1alpha1.SecuredApplicationList
+	if err := r.List(ctx, &list, client.MatchingFields{GroupRefIndexField: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "failed to look up SecuredApplications using an AccessGroup")
+		return nil
+	}
+	requests := make([]reconcile.Request, len(list.Items))
+	for i, app := range list.Items {
+		requests[i] = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: app.Namespace, Name: app.Name}}
+	}
+	return requests
+}
+
+// policiesUsingGroup enqueues the AccessPolicies that reference obj.
+func (r *AccessPolicyReconciler) policiesUsingGroup(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list accessv1alpha1.AccessPolicyList
+	if err := r.List(ctx, &list, client.MatchingFields{GroupRefIndexField: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "failed to look up AccessPolicies using an AccessGroup")
+		return nil
+	}
+	requests := make([]reconcile.Request, len(list.Items))
+	for i, policy := range list.Items {
+		requests[i] = reconcile.Request{NamespacedName: types.NamespacedName{Name: policy.Name}}
+	}
+	return requests
+}
+
+// groupsUsingGroup enqueues the other AccessGroups that reference obj.
+func (r *AccessGroupReconciler) groupsUsingGroup(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list accessv1alpha1.AccessGroupList
+	if err := r.List(ctx, &list, client.MatchingFields{GroupRefIndexField: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "failed to look up AccessGroups using an AccessGroup")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, group := range list.Items {
+		if group.Name != obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: group.Name}})
+		}
+	}
+	return requests
+}
+
+// resolveRefs returns the Refs for rules referencing the named AccessGroups.
+// It fails if a group does not exist or has not been created in Cloudflare
+// yet.
+func resolveRefs(ctx context.Context, c client.Reader, idpID string, groups []string) (builder.Refs, error) {
+	refs := builder.Refs{IdPID: idpID}
+	if len(groups) == 0 {
+		return refs, nil
+	}
+	refs.GroupIDs = make(map[string]string, len(groups))
+	for _, name := range groups {
+		var group accessv1alpha1.AccessGroup
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, &group); err != nil {
+			if apierrors.IsNotFound(err) {
+				return refs, fmt.Errorf("AccessGroup %q not found", name)
+			}
+			return refs, fmt.Errorf("get AccessGroup %q: %w", name, err)
+		}
+		if group.Status.GroupID == "" {
+			return refs, fmt.Errorf("AccessGroup %q has not been created in Cloudflare yet", name)
+		}
+		refs.GroupIDs[name] = group.Status.GroupID
+	}
+	return refs, nil
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/hosts"
+)
+
+// hostConflict returns a message describing the earlier claimant of one of
+// app's hosts, or "" if app may reconcile them.
+func (r *SecuredApplicationReconciler) hostConflict(ctx context.Context, app *accessv1alpha1.SecuredApplication) (string, error) {
+	conflicts, err := hosts.Conflicts(ctx, r.Client, app)
+	if err != nil {
+		return "", err
+	}
+	for _, host := range hosts.Claimed(app) {
+		for _, other := range conflicts[host] {
+			if hosts.ClaimsBefore(&other, app) {
+				return fmt.Sprintf("host %q is already claimed by SecuredApplication %s/%s", host, other.Namespace, other.Name), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// contestedHosts returns app's hosts that an earlier claimant manages.
+func (r *SecuredApplicationReconciler) contestedHosts(ctx context.Context, app *accessv1alpha1.SecuredApplication) (map[string]bool, error) {
+	conflicts, err := hosts.Conflicts(ctx, r.Client, app)
+	if err != nil {
+		return nil, err
+	}
+	contested := make(map[string]bool)
+	for host, others := range conflicts {
+		for _, other := range others {
+			if hosts.ClaimsBefore(&other, app) {
+				contested[host] = true
+			}
+		}
+	}
+	return contested, nil
+}
+
+// forgetContestedResources removes the Cloudflare resources on a contested
+// host from app's status before app is deleted. A losing claimant may still
+// hold the IDs of an Access Application it adopted before the conflict was
+// detected, which belongs to the earlier claimant now. Resources on its other
+// hosts, e.g. the ones it had before being moved onto a claimed host, stay
+// in the status and are deleted as usual.
+func (r *SecuredApplicationReconciler) forgetContestedResources(ctx context.Context, app *accessv1alpha1.SecuredApplication, contested map[string]bool) error {
+	onContestedHost := func(appID string) (bool, error) {
+		live, err := r.Cloudflare.GetAccessApp(ctx, appID)
+		if err != nil || live == nil {
+			return false, err
+		}
+		domains := []string{live.Domain}
+		for _, destination := range live.Destinations {
+			domains = append(domains, destination.URI)
+		}
+		for _, domain := range domains {
+			host, _, _ := strings.Cut(domain, "/")
+			if contested[strings.ToLower(host)] {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if app.Status.AccessApplicationID != "" {
+		contestedApp, err := onContestedHost(app.Status.AccessApplicationID)
+		if err != nil {
+			return fmt.Errorf("get Access Application %s: %w", app.Status.AccessApplicationID, err)
+		}
+		if contestedApp {
+			app.Status.AccessApplicationID = ""
+			app.Status.AccessPolicyID = ""
+		}
+	}
+	for path, appID := range app.Status.BypassApplicationIDs {
+		contestedApp, err := onContestedHost(appID)
+		if err != nil {
+			return fmt.Errorf("get bypass Access Application %s: %w", appID, err)
+		}
+		if contestedApp {
+			delete(app.Status.BypassApplicationIDs, path)
+		}
+	}
+	for path, appID := range app.Status.PathApplicationIDs {
+		contestedApp, err := onContestedHost(appID)
+		if err != nil {
+			return fmt.Errorf("get path Access Application %s: %w", appID, err)
+		}
+		if contestedApp {
+			delete(app.Status.PathApplicationIDs, path)
+			delete(app.Status.PathPolicyIDs, path)
+		}
+	}
+	app.Status.TunnelRoutes = slices.DeleteFunc(app.Status.TunnelRoutes, func(route accessv1alpha1.TunnelRouteStatus) bool {
+		return contested[strings.ToLower(route.Hostname)]
+	})
+	return nil
+}
+
+// appsSharingHosts enqueues the other SecuredApplications that claim one of
+// obj's hosts, so that a waiting claimant takes over once the host is freed.
+func (r *SecuredApplicationReconciler) appsSharingHosts(ctx context.Context, obj client.Object) []reconcile.Request {
+	app, ok := obj.(*accessv1alpha1.SecuredApplication)
+	if !ok {
+		return nil
+	}
+	conflicts, err := hosts.Conflicts(ctx, r.Client, app)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to look up SecuredApplications sharing a host")
+		return nil
+	}
+	var requests []reconcile.Request
+	seen := make(map[types.NamespacedName]bool)
+	for _, others := range conflicts {
+		for _, other := range others {
+			key := types.NamespacedName{Namespace: other.Namespace, Name: other.Name}
+			if !seen[key] {
+				seen[key] = true
+				requests = append(requests, reconcile.Request{NamespacedName: key})
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+)
+
+const (
+	routingModeIngress   = "Ingress"
+	routingModeHTTPRoute = "HTTPRoute"
+	routingModeTunnel    = "Tunnel"
+)
+
+func newHTTPRoute(name, namespace string) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(builder.HTTPRouteGVK)
+	route.SetName(name)
+	route.SetNamespace(namespace)
+	return route
+}
+
+// routingMode returns the configured routing mode, defaulting to Ingress.
+func routingMode(app *accessv1alpha1.SecuredApplication) string {
+	if app.Spec.Routing != nil && app.Spec.Routing.Mode != "" {
+		return app.Spec.Routing.Mode
+	}
+	return routingModeIngress
+}
+
+// reconcileHTTPRoute creates or updates the HTTPRoute named like desired,
+// owned by app.
+func (r *SecuredApplicationReconciler) reconcileHTTPRoute(ctx context.Context, app *accessv1alpha1.SecuredApplication, desired *unstructured.Unstructured) (controllerutil.OperationResult, error) {
+	route := newHTTPRoute(desired.GetName(), desired.GetNamespace())
+
+	return controllerutil.CreateOrUpdate(ctx, r.Client, route, func() error {
+		if err := controllerutil.SetControllerReference(app, route, r.Scheme); err != nil {
+			return err
+		}
+		route.Object["spec"] = desired.Object["spec"]
+		return nil
+	})
+}
+
+// deleteIfOwned deletes obj if it exists and is controlled by app. It is
+// used to clean up the Ingress or HTTPRoute left behind by a routing mode
+// switch or a removed nativeOIDC.ingress section.
+func (r *SecuredApplicationReconciler) deleteIfOwned(ctx context.Context, app *accessv1alpha1.SecuredApplication, obj client.Object) error {
+	owned, err := r.ownedObjectExists(ctx, app, obj)
+	if err != nil || !owned {
+		return err
+	}
+	if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ownedObjectExists fetches obj and reports whether it exists and is
+// controlled by app.
+func (r *SecuredApplicationReconciler) ownedObjectExists(ctx context.Context, app *accessv1alpha1.SecuredApplication, obj client.Object) (bool, error) {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return metav1.IsControlledBy(obj, app), nil
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// reconcilePathApps creates, repairs and removes the Access Applications of
+// spec.access.pathRules. Progress is recorded in app.Status.PathApplicationIDs
+// and PathPolicyIDs right away, so that a later failure does not orphan a
+// created application.
+func (r *SecuredApplicationReconciler) reconcilePathApps(ctx context.Context, app *accessv1alpha1.SecuredApplication, refs builder.Refs, report *driftReport) error {
+	logger := log.FromContext(ctx)
+	desired := builder.PathApps(app, refs, r.Config.SessionDuration)
+
+	// Delete path apps for paths that are no longer in the spec.
+	for _, path := range slices.Sorted(maps.Keys(app.Status.PathApplicationIDs)) {
+		if slices.ContainsFunc(desired, func(p builder.PathApp) bool { return p.Path == path }) {
+			continue
+		}
+		appID := app.Status.PathApplicationIDs[path]
+		logger.Info("removing stale path Access Application", "path", path, "appId", appID)
+		if err := r.Cloudflare.DeleteAccessApp(ctx, appID); err != nil {
+			return fmt.Errorf("delete stale path app for %q: %w", path, err)
+		}
+		r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "DeletedPathApp", "Delete", "Deleted path Access Application %s for %s", appID, path)
+		delete(app.Status.PathApplicationIDs, path)
+		delete(app.Status.PathPolicyIDs, path)
+	}
+
+	for _, pathApp := range desired {
+		if err := r.reconcilePathApp(ctx, app, pathApp, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcilePathApp brings one path app and its allow policy in line with the
+// spec, like the main Access Application.
+func (r *SecuredApplicationReconciler) reconcilePathApp(ctx context.Context, app *accessv1alpha1.SecuredApplication, pathApp builder.PathApp, report *driftReport) error {
+	logger := log.FromContext(ctx)
+	desired := pathApp.App
+	appID := app.Status.PathApplicationIDs[pathApp.Path]
+	policyID := app.Status.PathPolicyIDs[pathApp.Path]
+
+	var drift *cfclient.Drift
+	if appID != "" {
+		var err error
+		drift, err = r.Cloudflare.DetectDrift(ctx, appID, policyID, desired)
+		if err != nil {
+			return fmt.Errorf("detect drift of path app for %q: %w", pathApp.Path, err)
+		}
+		if drift.AppMissing {
+			logger.Info("path Access Application no longer exists, recreating", "path", pathApp.Path, "appId", appID)
+			appID = ""
+			policyID = ""
+		} else if drift.PolicyMissing {
+			policyID = ""
+		}
+		report.add(drift)
+	}
+
+	inSync := drift != nil && !drift.Drifted()
+	if appID != "" && !inSync {
+		err := r.Cloudflare.UpdateAccessApp(ctx, appID, desired.Name, desired.Domains, desired.SessionDuration, nil)
+		switch {
+		case cfclient.IsNotFound(err):
+			// Deleted since the drift check; recreate it below.
+			logger.Info("path Access Application no longer exists, recreating", "path", pathApp.Path, "appId", appID)
+			appID = ""
+			policyID = ""
+		case err != nil:
+			return fmt.Errorf("update path app for %q: %w", pathApp.Path, err)
+		default:
+			r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "UpdatedPathApp", "Update", "Updated path Access Application %s for %s", appID, pathApp.Path)
+		}
+	}
+	if appID == "" {
+		created, err := r.Cloudflare.CreateAccessApp(ctx, desired.Name, desired.Domains, desired.SessionDuration)
+		if err != nil {
+			return fmt.Errorf("create path app for %q: %w", pathApp.Path, err)
+		}
+		appID = created.ID
+		logger.Info("created path Access Application", "path", pathApp.Path, "appId", appID)
+		r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "CreatedPathApp", "Create", "Created path Access Application %s for %s", appID, strings.Join(desired.Domains, ", "))
+	}
+	if app.Status.PathApplicationIDs == nil {
+		app.Status.PathApplicationIDs = map[string]string{}
+	}
+	app.Status.PathApplicationIDs[pathApp.Path] = appID
+
+	if inSync {
+		return nil
+	}
+	policy, err := r.Cloudflare.UpsertAccessPolicy(ctx, appID, policyID, cfclient.AllowPolicyBody(desired.Policy))
+	if err != nil {
+		return fmt.Errorf("upsert policy of path app for %q: %w", pathApp.Path, err)
+	}
+	if app.Status.PathPolicyIDs == nil {
+		app.Status.PathPolicyIDs = map[string]string{}
+	}
+	app.Status.PathPolicyIDs[pathApp.Path] = policy.ID
+	r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "UpdatedPathPolicy", "Update", "Updated Access policy %s for %s", policy.ID, pathApp.Path)
+	return nil
+}
+
+// planPathApps mirrors reconcilePathApps.
+func (r *SecuredApplicationReconciler) planPathApps(ctx context.Context, app *accessv1alpha1.SecuredApplication, refs builder.Refs) ([]string, error) {
+	var plan []string
+	desired := builder.PathApps(app, refs, r.Config.SessionDuration)
+	for _, path := range slices.Sorted(maps.Keys(app.Status.PathApplicationIDs)) {
+		if !slices.ContainsFunc(desired, func(p builder.PathApp) bool { return p.Path == path }) {
+			plan = append(plan, fmt.Sprintf("delete path Access Application %s for %s", app.Status.PathApplicationIDs[path], path))
+		}
+	}
+	for _, pathApp := range desired {
+		appID := app.Status.PathApplicationIDs[pathApp.Path]
+		if appID != "" {
+			drift, err := r.Cloudflare.DetectDrift(ctx, appID, app.Status.PathPolicyIDs[pathApp.Path], pathApp.App)
+			if err != nil {
+				return nil, fmt.Errorf("detect drift of path app for %q: %w", pathApp.Path, err)
+			}
+			if !drift.AppMissing {
+				for _, change := range drift.Changes {
+					plan = append(plan, fmt.Sprintf("update path Access Application %s: %s", appID, change))
+				}
+				if drift.PolicyMissing {
+					plan = append(plan, fmt.Sprintf("create Access policy for %s with %s", pathApp.Path, describePolicy(pathApp.App.Policy)))
+				}
+				continue
+			}
+			plan = append(plan, fmt.Sprintf("path Access Application %s no longer exists", appID))
+		}
+		plan = append(plan,
+			fmt.Sprintf("create path Access Application %q for %s", pathApp.App.Name, strings.Join(pathApp.App.Domains, ", ")),
+			fmt.Sprintf("create Access policy for %s with %s", pathApp.Path, describePolicy(pathApp.App.Policy)))
+	}
+	return plan, nil
+}
+
+// deletePathApps deletes the path apps in status, forgetting each one once
+// it is gone.
+func (r *SecuredApplicationReconciler) deletePathApps(ctx context.Context, app *accessv1alpha1.SecuredApplication) error {
+	logger := log.FromContext(ctx)
+	for _, path := range slices.Sorted(maps.Keys(app.Status.PathApplicationIDs)) {
+		appID := app.Status.PathApplicationIDs[path]
+		logger.Info("deleting path Access Application", "path", path, "appId", appID)
+		if err := r.Cloudflare.DeleteAccessApp(ctx, appID); err != nil {
+			return fmt.Errorf("delete path Access Application %s for %s: %w", appID, path, err)
+		}
+		r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "DeletedPathApp", "Delete", "Deleted path Access Application %s for %s", appID, path)
+		delete(app.Status.PathApplicationIDs, path)
+		delete(app.Status.PathPolicyIDs, path)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// PolicyRefIndexField indexes SecuredApplications by the AccessPolicies they
+// attach. It is registered by SecuredApplicationReconciler.SetupWithManager
+// and also used by the AccessPolicy controller.
+const PolicyRefIndexField = "spec.access.policyRefs"
+
+func indexPolicyRefs(obj client.Object) []string {
+	return obj.(*accessv1alpha1.SecuredApplication).Spec.Access.PolicyRefs
+}
+
+// policyUsers returns the SecuredApplications that attach the named AccessPolicy.
+func policyUsers(ctx context.Context, c client.Reader, name string) ([]accessv1alpha1.SecuredApplication, error) {
+	var list accessv1alpha1.SecuredApplicationList
+	if err := c.List(ctx, &list, client.MatchingFields{PolicyRefIndexField: name}); err != nil {
+		return nil, fmt.Errorf("list SecuredApplications using AccessPolicy %s: %w", name, err)
+	}
+	return list.Items, nil
+}
+
+// appsUsingPolicy enqueues the SecuredApplications that attach obj, so that
+// they pick up its policy ID once it is created or recreated.
+func (r *SecuredApplicationReconciler) appsUsingPolicy(ctx context.Context, obj client.Object) []reconcile.Request {
+	apps, err := policyUsers(ctx, r.Client, obj.GetName())
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to look up SecuredApplications using an AccessPolicy")
+		return nil
+	}
+	requests := make([]reconcile.Request, len(apps))
+	for i, app := range apps {
+		requests[i] = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: app.Namespace, Name: app.Name}}
+	}
+	return requests
+}
+
+// resolvePolicyRefs returns the Cloudflare policy ID of every AccessPolicy in
+// spec.access.policyRefs, by name. It fails if one does not exist or has not
+// been created in Cloudflare yet.
+func (r *SecuredApplicationReconciler) resolvePolicyRefs(ctx context.Context, app *accessv1alpha1.SecuredApplication) (map[string]string, error) {
+	if len(app.Spec.Access.PolicyRefs) == 0 {
+		return nil, nil
+	}
+	ids := make(map[string]string, len(app.Spec.Access.PolicyRefs))
+	for _, name := range app.Spec.Access.PolicyRefs {
+		var policy accessv1alpha1.AccessPolicy
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, &policy); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("AccessPolicy %q not found", name)
+			}
+			return nil, fmt.Errorf("get AccessPolicy %q: %w", name, err)
+		}
+		if policy.Status.PolicyID == "" {
+			return nil, fmt.Errorf("AccessPolicy %q has not been created in Cloudflare yet", name)
+		}
+		ids[name] = policy.Status.PolicyID
+	}
+	return ids, nil
+}
+
+// desiredAccessApp builds the desired Access Application. Attached reusable
+// policies are only checked while app references or referenced some, so that
+// policies attached outside the operator are left alone.
+func (r *SecuredApplicationReconciler) desiredAccessApp(app *accessv1alpha1.SecuredApplication, refs builder.Refs, reusableIDs map[string]string) cfclient.DesiredAccessApp {
+	desired := builder.AccessApp(app, refs, r.Config.SessionDuration)
+	if len(app.Spec.Access.PolicyRefs) > 0 || len(app.Status.ReusablePolicyIDs) > 0 {
+		desired.ReusablePolicies = make([]string, 0, len(app.Spec.Access.PolicyRefs))
+		for _, name := range app.Spec.Access.PolicyRefs {
+			desired.ReusablePolicies = append(desired.ReusablePolicies, reusableIDs[name])
+		}
+	}
+	return desired
+}
+
+// policyLinks lists every policy of the Access Application with its
+// precedence: the generated allow policy, spec.access.policies, and then the
+// reusable policies in order.
+func policyLinks(app *accessv1alpha1.SecuredApplication, allowPolicyID string, policyIDs map[string]string, reusable []string) []cfclient.AppPolicyLink {
+	links := []cfclient.AppPolicyLink{}
+	last := 0
+	if allowPolicyID != "" {
+		links = append(links, cfclient.AppPolicyLink{ID: allowPolicyID, Precedence: 1})
+		last = 1
+	}
+	for _, policy := range builder.AccessPolicies(app, builder.Refs{}) {
+		if id := policyIDs[policy.Name]; id != "" {
+			links = append(links, cfclient.AppPolicyLink{ID: id, Precedence: policy.Precedence})
+			last = max(last, policy.Precedence)
+		}
+	}
+	for _, id := range reusable {
+		last++
+		links = append(links, cfclient.AppPolicyLink{ID: id, Precedence: last})
+	}
+	return links
+}
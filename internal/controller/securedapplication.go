@@ -2,7 +2,11 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -11,24 +15,27 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
 	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/hosts"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/metrics"
 	"github.com/twiechert/cf-zitadel-access-operator/internal/zitadel"
 )
 
 const (
 	finalizerName = "access.twiechert.de/finalizer"
 
-	// The custom:roles claim is a flat array produced by a Zitadel Action (flatRoles).
-	// Cloudflare Access can't match Zitadel's default nested role claim format.
-	roleClaimName = "custom:roles"
-
-	cfBackendProtocolAnnotation = "cloudflare-tunnel-ingress-controller.strrl.dev/backend-protocol"
+	// Setting this annotation to a new value (e.g. a timestamp) triggers a
+	// one-off client secret rotation.
+	rotateSecretAnnotation = "access.twiechert.de/rotate-secret"
 )
 
 // Config holds operator-level configuration.
@@ -36,8 +43,24 @@ type Config struct {
 	// CloudflareIdPID is the Cloudflare Access Identity Provider ID for Zitadel.
 	CloudflareIdPID string
 
+	// TunnelName is the Cloudflare Tunnel used in Tunnel routing mode when
+	// spec.routing.tunnelName is not set.
+	TunnelName string
+
 	// SessionDuration is the Cloudflare Access session duration (e.g. "24h").
 	SessionDuration string
+
+	// EnableGatewayAPI allows HTTPRoute routing mode and makes the controller
+	// watch HTTPRoutes. Requires the Gateway API CRDs to be installed.
+	EnableGatewayAPI bool
+
+	// ResyncInterval is how often a reconciled SecuredApplication is checked
+	// for drift in Cloudflare. Zero disables periodic resync.
+	ResyncInterval time.Duration
+
+	// DryRun makes the operator only compute and report what it would change
+	// for every SecuredApplication, without changing anything.
+	DryRun bool
 }
 
 type SecuredApplicationReconciler struct {
@@ -46,13 +69,20 @@ type SecuredApplicationReconciler struct {
 	Zitadel    zitadel.Client
 	Cloudflare cfclient.Client
 	Config     Config
+
+	// Recorder emits an Event on the SecuredApplication for every change
+	// made in Zitadel or Cloudflare and for every failed reconcile.
+	Recorder events.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=access.twiechert.de,resources=securedapplications,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=access.twiechert.de,resources=securedapplications/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=access.twiechert.de,resources=securedapplications/finalizers,verbs=update
+// +kubebuilder:rbac:groups=access.twiechert.de,resources=accesspolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 
 func (r *SecuredApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -68,27 +98,69 @@ func (r *SecuredApplicationReconciler) Reconcile(ctx context.Context, req ctrl.R
 	// Handle deletion.
 	if !app.DeletionTimestamp.IsZero() {
 		if controllerutil.ContainsFinalizer(&app, finalizerName) {
-			if !app.Spec.DeleteProtection {
+			// Cloudflare resources on a host another SecuredApplication
+			// claimed first belong to that object.
+			contested, err := r.contestedHosts(ctx, &app)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if len(contested) > 0 {
+				logger.Info("hosts are claimed by another SecuredApplication, keeping their Cloudflare resources", "hosts", slices.Sorted(maps.Keys(contested)))
+				if err := r.forgetContestedResources(ctx, &app, contested); err != nil {
+					logger.Error(err, "failed to look up Cloudflare resources on claimed hosts, will retry")
+					return ctrl.Result{RequeueAfter: deleteRequeue(err)}, nil
+				}
+			}
+			if r.dryRun(&app) {
+				logger.Info("dry run, keeping external resources")
+				r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "Planned", "Plan",
+					"Dry run: not deleting Zitadel app %q, Access Application %q, %d bypass apps and %d path apps", app.Status.ZitadelAppID, app.Status.AccessApplicationID, len(app.Status.BypassApplicationIDs), len(app.Status.PathApplicationIDs))
+			} else if !app.Spec.DeleteProtection {
 				if app.Status.ZitadelAppID != "" && app.Status.ProjectID != "" {
 					logger.Info("deleting Zitadel OIDC app", "appId", app.Status.ZitadelAppID)
 					if err := r.Zitadel.DeleteApp(ctx, app.Status.ProjectID, app.Status.ZitadelAppID); err != nil {
 						logger.Error(err, "failed to delete Zitadel app, will retry")
-						return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+						r.Recorder.Eventf(&app, nil, corev1.EventTypeWarning, "DeleteFailed", "Delete", "Failed to delete Zitadel OIDC app %s: %v", app.Status.ZitadelAppID, err)
+						return ctrl.Result{RequeueAfter: deleteRequeue(err)}, nil
 					}
+					r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "DeletedZitadelApp", "Delete", "Deleted Zitadel OIDC app %s", app.Status.ZitadelAppID)
 				}
 				if app.Status.AccessApplicationID != "" {
 					logger.Info("deleting Cloudflare Access Application", "appId", app.Status.AccessApplicationID)
 					if err := r.Cloudflare.DeleteAccessApp(ctx, app.Status.AccessApplicationID); err != nil {
 						logger.Error(err, "failed to delete Access Application, will retry")
-						return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+						r.Recorder.Eventf(&app, nil, corev1.EventTypeWarning, "DeleteFailed", "Delete", "Failed to delete Access Application %s: %v", app.Status.AccessApplicationID, err)
+						return ctrl.Result{RequeueAfter: deleteRequeue(err)}, nil
 					}
+					r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "DeletedAccessApplication", "Delete", "Deleted Access Application %s", app.Status.AccessApplicationID)
 				}
 				for path, appID := range app.Status.BypassApplicationIDs {
 					logger.Info("deleting bypass Access Application", "path", path, "appId", appID)
 					if err := r.Cloudflare.DeleteAccessApp(ctx, appID); err != nil {
 						logger.Error(err, "failed to delete bypass Access Application, will retry")
-						return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+						r.Recorder.Eventf(&app, nil, corev1.EventTypeWarning, "DeleteFailed", "Delete", "Failed to delete bypass Access Application %s for %s: %v", appID, path, err)
+						return ctrl.Result{RequeueAfter: deleteRequeue(err)}, nil
 					}
+					r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "DeletedBypassApp", "Delete", "Deleted bypass Access Application %s for %s", appID, path)
+				}
+				if err := r.deletePathApps(ctx, &app); err != nil {
+					logger.Error(err, "failed to delete path Access Application, will retry")
+					r.Recorder.Eventf(&app, nil, corev1.EventTypeWarning, "DeleteFailed", "Delete", "Failed to delete path Access Application: %v", err)
+					return ctrl.Result{RequeueAfter: deleteRequeue(err)}, nil
+				}
+				for name, token := range app.Status.ServiceTokens {
+					logger.Info("deleting service token", "name", name, "tokenId", token.ID)
+					if err := r.Cloudflare.DeleteServiceToken(ctx, token.ID); err != nil {
+						logger.Error(err, "failed to delete service token, will retry")
+						r.Recorder.Eventf(&app, nil, corev1.EventTypeWarning, "DeleteFailed", "Delete", "Failed to delete service token %s (%s): %v", token.ID, name, err)
+						return ctrl.Result{RequeueAfter: deleteRequeue(err)}, nil
+					}
+					r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "DeletedServiceToken", "Delete", "Deleted service token %s (%s)", token.ID, name)
+				}
+				if err := r.deleteTunnelRoutes(ctx, &app); err != nil {
+					logger.Error(err, "failed to delete tunnel route, will retry")
+					r.Recorder.Eventf(&app, nil, corev1.EventTypeWarning, "DeleteFailed", "Delete", "Failed to delete tunnel route: %v", err)
+					return ctrl.Result{RequeueAfter: deleteRequeue(err)}, nil
 				}
 			} else {
 				logger.Info("delete protection enabled, keeping external resources")
@@ -102,6 +174,37 @@ func (r *SecuredApplicationReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, nil
 	}
 
+	app.Status.Hosts = hosts.Claimed(&app)
+
+	// Only the earliest claimant of a host manages its Cloudflare resources.
+	conflict, err := r.hostConflict(ctx, &app)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if conflict != "" {
+		logger.Info("skipping reconcile, host conflict", "conflict", conflict)
+		markCondition(&app, accessv1alpha1.ConditionHostConflict, metav1.ConditionTrue, "HostClaimed", conflict)
+		return r.setCondition(ctx, &app, metav1.ConditionFalse, "HostConflict", conflict)
+	}
+	meta.RemoveStatusCondition(&app.Status.Conditions, accessv1alpha1.ConditionHostConflict)
+
+	// Flip Ready to Unknown as soon as a new generation is picked up, so that
+	// `kubectl wait --for=condition=Ready` does not see the previous result.
+	if ready := meta.FindStatusCondition(app.Status.Conditions, accessv1alpha1.ConditionReady); ready != nil && ready.ObservedGeneration != app.Generation {
+		markCondition(&app, accessv1alpha1.ConditionReady, metav1.ConditionUnknown, "Reconciling", "Reconciling the new spec")
+		app.Status.Ready = false
+		if err := r.Status().Update(ctx, &app); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// In dry-run mode only report what would change. The finalizer is not
+	// added either, since that would be a change.
+	if r.dryRun(&app) {
+		return r.reconcileDryRun(ctx, &app)
+	}
+	app.Status.Plan = nil
+
 	// Add finalizer.
 	if !controllerutil.ContainsFinalizer(&app, finalizerName) {
 		controllerutil.AddFinalizer(&app, finalizerName)
@@ -113,117 +216,257 @@ func (r *SecuredApplicationReconciler) Reconcile(ctx context.Context, req ctrl.R
 	// 1. Resolve Zitadel project name → ID.
 	project, err := r.Zitadel.GetProjectByName(ctx, app.Spec.Access.Project)
 	if err != nil {
-		return r.setCondition(ctx, &app, metav1.ConditionFalse, "ProjectLookupFailed", err.Error())
+		var ambiguous *zitadel.AmbiguousError
+		if errors.As(err, &ambiguous) {
+			return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "ProjectAmbiguous", err)
+		}
+		return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "ProjectLookupFailed", err)
 	}
 	if project == nil {
-		return r.setCondition(ctx, &app, metav1.ConditionFalse, "ProjectNotFound",
-			fmt.Sprintf("Zitadel project %q not found", app.Spec.Access.Project))
+		msg := fmt.Sprintf("Zitadel project %q not found", app.Spec.Access.Project)
+		markCondition(&app, accessv1alpha1.ConditionZitadelAppReady, metav1.ConditionFalse, "ProjectNotFound", msg)
+		return r.setCondition(ctx, &app, metav1.ConditionFalse, "ProjectNotFound", msg)
 	}
 
 	// 2. Validate that all requested roles exist (only if roles are specified).
-	if len(app.Spec.Access.Roles) > 0 {
+	if requested := requestedRoles(&app); len(requested) > 0 {
 		existingRoles, err := r.Zitadel.ListProjectRoles(ctx, project.ID)
 		if err != nil {
-			return r.setCondition(ctx, &app, metav1.ConditionFalse, "RoleLookupFailed", err.Error())
+			return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "RoleLookupFailed", err)
 		}
 		roleSet := make(map[string]bool, len(existingRoles))
 		for _, role := range existingRoles {
 			roleSet[role.Key] = true
 		}
-		for _, requested := range app.Spec.Access.Roles {
-			if !roleSet[requested] {
-				return r.setCondition(ctx, &app, metav1.ConditionFalse, "RoleNotFound",
-					fmt.Sprintf("role %q does not exist in Zitadel project %q", requested, app.Spec.Access.Project))
+		for _, role := range requested {
+			if !roleSet[role] {
+				msg := fmt.Sprintf("role %q does not exist in Zitadel project %q", role, app.Spec.Access.Project)
+				markCondition(&app, accessv1alpha1.ConditionZitadelAppReady, metav1.ConditionFalse, "RoleNotFound", msg)
+				return r.setCondition(ctx, &app, metav1.ConditionFalse, "RoleNotFound", msg)
 			}
 		}
 	}
 
-	// Validate that at least one of roles or claims is specified.
-	if len(app.Spec.Access.Roles) == 0 && len(app.Spec.Access.Claims) == 0 {
-		return r.setCondition(ctx, &app, metav1.ConditionFalse, "InvalidAccess",
-			"at least one of roles or claims must be specified")
+	// Validate that the application gets at least one policy.
+	if !hasAccessPolicies(&app) {
+		msg := "at least one of roles, claims, include, policies, policyRefs or serviceTokens must be specified"
+		markCondition(&app, accessv1alpha1.ConditionAccessPolicyReady, metav1.ConditionFalse, "InvalidAccess", msg)
+		return r.setCondition(ctx, &app, metav1.ConditionFalse, "InvalidAccess", msg)
 	}
 
 	// 3. Reconcile Zitadel OIDC application.
 	oidcApp, clientSecret, err := r.reconcileZitadelApp(ctx, &app, project.ID)
 	if err != nil {
-		return r.setCondition(ctx, &app, metav1.ConditionFalse, "ZitadelAppFailed", err.Error())
+		var ambiguous *zitadel.AmbiguousError
+		if errors.As(err, &ambiguous) {
+			return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "ZitadelAppAmbiguous", err)
+		}
+		return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "ZitadelAppFailed", err)
 	}
 	logger.Info("reconciled Zitadel OIDC app", "appId", oidcApp.ID, "clientId", oidcApp.ClientID)
 
-	// Write credentials to K8s Secret (only on initial creation when we have the client secret).
+	// Zitadel never returns an existing client secret, so if the credential
+	// Secret was deleted or emptied the only way to repopulate it is to
+	// generate a new one.
+	if clientSecret == "" && usesClientSecret(&app) {
+		missing, err := r.credentialSecretMissing(ctx, &app, oidcApp.ClientID)
+		if err != nil {
+			return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "SecretFailed", err)
+		}
+		if missing {
+			logger.Info("credential Secret is missing or incomplete, regenerating client secret", "secret", credentialSecretName(&app))
+			markCondition(&app, accessv1alpha1.ConditionCredentialsMissing, metav1.ConditionTrue, "SecretMissing",
+				fmt.Sprintf("Secret %q is missing or incomplete; regenerating the Zitadel client secret", credentialSecretName(&app)))
+			clientSecret, err = r.Zitadel.RegenerateClientSecret(ctx, project.ID, oidcApp.ID)
+			if err != nil {
+				return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "SecretRegenerationFailed", err)
+			}
+			r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "RegeneratedClientSecret", "Rotate",
+				"Regenerated the client secret of Zitadel OIDC app %s because Secret %s was missing", oidcApp.ID, credentialSecretName(&app))
+		}
+	}
+
+	// Regenerate the client secret when rotation was requested or is due.
+	if clientSecret == "" && secretRotationDue(&app, time.Now()) {
+		clientSecret, err = r.Zitadel.RegenerateClientSecret(ctx, project.ID, oidcApp.ID)
+		if err != nil {
+			return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "SecretRotationFailed", err)
+		}
+		logger.Info("rotated Zitadel client secret", "appId", oidcApp.ID)
+		r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "RotatedClientSecret", "Rotate", "Rotated the client secret of Zitadel OIDC app %s", oidcApp.ID)
+	}
+
+	// Write credentials to K8s Secret (only when Zitadel handed us a client
+	// secret, i.e. on creation or rotation).
 	if clientSecret != "" {
 		if err := r.writeCredentialSecret(ctx, &app, oidcApp.ClientID, clientSecret); err != nil {
-			return r.setCondition(ctx, &app, metav1.ConditionFalse, "SecretFailed", err.Error())
+			return r.fail(ctx, &app, accessv1alpha1.ConditionZitadelAppReady, "SecretFailed", err)
 		}
+		now := metav1.Now()
+		app.Status.LastSecretRotation = &now
+		app.Status.LastRotationRequest = app.Annotations[rotateSecretAnnotation]
+		markCondition(&app, accessv1alpha1.ConditionCredentialsMissing, metav1.ConditionFalse, "SecretWritten",
+			fmt.Sprintf("Client credentials written to Secret %q", credentialSecretName(&app)))
+	}
+	markCondition(&app, accessv1alpha1.ConditionZitadelAppReady, metav1.ConditionTrue, "Reconciled",
+		fmt.Sprintf("Zitadel OIDC app %s is up to date", oidcApp.ID))
+
+	// Create service tokens before the policy that admits them.
+	if err := r.reconcileServiceTokens(ctx, &app); err != nil {
+		return r.fail(ctx, &app, accessv1alpha1.ConditionServiceTokensReady, "ServiceTokenFailed", err)
+	}
+	if len(app.Spec.Access.ServiceTokens) > 0 {
+		markCondition(&app, accessv1alpha1.ConditionServiceTokensReady, metav1.ConditionTrue, "Reconciled",
+			fmt.Sprintf("%d service tokens are up to date", len(app.Spec.Access.ServiceTokens)))
+	} else {
+		markCondition(&app, accessv1alpha1.ConditionServiceTokensReady, metav1.ConditionTrue, "NotRequired", "No service tokens configured")
 	}
 
 	// 4. Reconcile Cloudflare Access Application with OIDC claim policy.
+	reusableIDs, err := r.resolvePolicyRefs(ctx, &app)
+	if err != nil {
+		return r.fail(ctx, &app, accessv1alpha1.ConditionAccessPolicyReady, "PolicyRefNotReady", err)
+	}
+	refs, err := resolveRefs(ctx, r.Client, r.Config.CloudflareIdPID, appGroupRefs(&app))
+	if err != nil {
+		return r.fail(ctx, &app, accessv1alpha1.ConditionAccessPolicyReady, "GroupRefNotReady", err)
+	}
+	refs.ServiceTokenIDs = serviceTokenIDs(&app)
+	desired := r.desiredAccessApp(&app, refs, reusableIDs)
+
 	accessAppID := app.Status.AccessApplicationID
+	policyID := app.Status.AccessPolicyID
+
+	// Compare the live app and policy against the spec so that edits made in
+	// the Cloudflare dashboard are detected and reverted. Drift of every
+	// Access Application is collected into one Drifted condition.
+	report := &driftReport{app: &app}
+	var drift *cfclient.Drift
+	if accessAppID != "" {
+		drift, err = r.Cloudflare.DetectDrift(ctx, accessAppID, policyID, desired)
+		if err != nil {
+			return r.fail(ctx, &app, accessv1alpha1.ConditionAccessApplicationReady, "CloudflareLookupFailed", err)
+		}
+		if drift.AppMissing {
+			logger.Info("Access Application no longer exists, recreating", "appId", accessAppID)
+			accessAppID = ""
+			policyID = ""
+		} else if drift.PolicyMissing {
+			policyID = ""
+		}
+		report.add(drift)
+	}
+
 	if accessAppID == "" {
-		existing, err := r.Cloudflare.FindAccessAppByDomain(ctx, app.Spec.Host)
+		existing, err := r.Cloudflare.FindAccessAppByDomain(ctx, desired.Domains[0])
 		if err != nil {
-			return r.setCondition(ctx, &app, metav1.ConditionFalse, "CloudflareLookupFailed", err.Error())
+			return r.fail(ctx, &app, accessv1alpha1.ConditionAccessApplicationReady, "CloudflareLookupFailed", err)
 		}
 		if existing != nil {
 			logger.Info("adopting existing Access Application", "appId", existing.ID)
+			metrics.RecordAdoption(metrics.KindAccessApplication)
+			r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "AdoptedAccessApplication", "Adopt", "Adopted existing Access Application %s for %s", existing.ID, desired.Domains[0])
 			accessAppID = existing.ID
 		}
 	}
 
-	if accessAppID != "" {
-		if err := r.Cloudflare.UpdateAccessApp(ctx, accessAppID, app.Name, app.Spec.Host, r.Config.SessionDuration); err != nil {
-			return r.setCondition(ctx, &app, metav1.ConditionFalse, "CloudflareUpdateFailed", err.Error())
+	inSync := drift != nil && !drift.Drifted()
+	if accessAppID != "" && !inSync {
+		err := r.Cloudflare.UpdateAccessApp(ctx, accessAppID, app.Name, desired.Domains, r.Config.SessionDuration, nil)
+		switch {
+		case cfclient.IsNotFound(err):
+			// Deleted since the drift check; recreate it below.
+			logger.Info("Access Application no longer exists, recreating", "appId", accessAppID)
+			accessAppID = ""
+			policyID = ""
+			drift = nil
+		case err != nil:
+			return r.fail(ctx, &app, accessv1alpha1.ConditionAccessApplicationReady, "CloudflareUpdateFailed", err)
+		default:
+			r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "UpdatedAccessApplication", "Update", "Updated Access Application %s", accessAppID)
 		}
-	} else {
-		created, err := r.Cloudflare.CreateAccessApp(ctx, app.Name, app.Spec.Host, r.Config.SessionDuration)
+	}
+	if accessAppID == "" {
+		created, err := r.Cloudflare.CreateAccessApp(ctx, app.Name, desired.Domains, r.Config.SessionDuration)
 		if err != nil {
-			return r.setCondition(ctx, &app, metav1.ConditionFalse, "CloudflareCreateFailed", err.Error())
+			return r.fail(ctx, &app, accessv1alpha1.ConditionAccessApplicationReady, "CloudflareCreateFailed", err)
 		}
 		accessAppID = created.ID
 		logger.Info("created Access Application", "appId", accessAppID)
+		r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "CreatedAccessApplication", "Create", "Created Access Application %s for %s", accessAppID, strings.Join(desired.Domains, ", "))
 	}
+	markCondition(&app, accessv1alpha1.ConditionAccessApplicationReady, metav1.ConditionTrue, "Reconciled",
+		fmt.Sprintf("Access Application %s is up to date", accessAppID))
 
-	// Build CF Access policy rules from both roles and claims.
-	var rules []cfclient.OIDCClaimRule
-	for _, role := range app.Spec.Access.Roles {
-		rules = append(rules, cfclient.OIDCClaimRule{
-			IdentityProviderID: r.Config.CloudflareIdPID,
-			ClaimName:          roleClaimName,
-			ClaimValue:         role,
-		})
-	}
-	for _, claim := range app.Spec.Access.Claims {
-		rules = append(rules, cfclient.OIDCClaimRule{
-			IdentityProviderID: r.Config.CloudflareIdPID,
-			ClaimName:          claim.Name,
-			ClaimValue:         claim.Value,
-		})
+	switch {
+	case !builder.HasAllowPolicy(&app):
+		if policyID != "" {
+			if err := r.Cloudflare.DeleteAccessPolicy(ctx, accessAppID, policyID); err != nil {
+				return r.fail(ctx, &app, accessv1alpha1.ConditionAccessPolicyReady, "PolicyFailed", err)
+			}
+			r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "DeletedAccessPolicy", "Delete", "Deleted Access policy %s", policyID)
+			policyID = ""
+		}
+	case !inSync:
+		policy, err := r.Cloudflare.UpsertAccessPolicy(ctx, accessAppID, policyID, cfclient.AllowPolicyBody(desired.Policy))
+		if err != nil {
+			return r.fail(ctx, &app, accessv1alpha1.ConditionAccessPolicyReady, "PolicyFailed", err)
+		}
+		policyID = policy.ID
+		r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "UpdatedAccessPolicy", "Update", "Updated Access policy %s", policyID)
 	}
 
-	policy, err := r.Cloudflare.UpsertAccessPolicy(ctx, accessAppID, app.Status.AccessPolicyID, rules)
+	policyIDs, err := r.reconcileAccessPolicies(ctx, &app, refs, accessAppID, report)
 	if err != nil {
-		return r.setCondition(ctx, &app, metav1.ConditionFalse, "PolicyFailed", err.Error())
+		return r.fail(ctx, &app, accessv1alpha1.ConditionAccessPolicyReady, "PolicyFailed", err)
+	}
+
+	// Attaching reusable policies replaces the application's policy list,
+	// so it is done once the application's own policies exist.
+	if desired.ReusablePolicies != nil && (drift == nil || drift.AppMissing || drift.ReusablePoliciesChanged) {
+		links := policyLinks(&app, policyID, policyIDs, desired.ReusablePolicies)
+		if err := r.Cloudflare.UpdateAccessApp(ctx, accessAppID, app.Name, desired.Domains, r.Config.SessionDuration, links); err != nil {
+			return r.fail(ctx, &app, accessv1alpha1.ConditionAccessPolicyReady, "PolicyFailed", err)
+		}
+		r.Recorder.Eventf(&app, nil, corev1.EventTypeNormal, "AttachedReusablePolicies", "Update",
+			"Attached %d reusable Access policies to Access Application %s", len(desired.ReusablePolicies), accessAppID)
+	}
+	markCondition(&app, accessv1alpha1.ConditionAccessPolicyReady, metav1.ConditionTrue, "Reconciled",
+		fmt.Sprintf("Access policies of Access Application %s are up to date", accessAppID))
+
+	// Tokens removed from the spec are no longer admitted by any policy.
+	if err := r.deleteStaleServiceTokens(ctx, &app); err != nil {
+		return r.fail(ctx, &app, accessv1alpha1.ConditionServiceTokensReady, "ServiceTokenFailed", err)
 	}
 
 	// 5. Reconcile bypass Access Applications for unauthenticated paths.
-	bypassIDs, err := r.reconcileBypassApps(ctx, &app)
+	bypassIDs, err := r.reconcileBypassApps(ctx, &app, report)
 	if err != nil {
-		return r.setCondition(ctx, &app, metav1.ConditionFalse, "BypassAppFailed", err.Error())
+		return r.fail(ctx, &app, accessv1alpha1.ConditionBypassAppsReady, "BypassAppFailed", err)
+	}
+	if len(bypassIDs) > 0 {
+		markCondition(&app, accessv1alpha1.ConditionBypassAppsReady, metav1.ConditionTrue, "Reconciled",
+			fmt.Sprintf("%d bypass Access Applications are up to date", len(bypassIDs)))
+	} else {
+		markCondition(&app, accessv1alpha1.ConditionBypassAppsReady, metav1.ConditionTrue, "NotRequired", "No bypass paths configured")
 	}
 
-	// 6. Reconcile Cloudflare Tunnel Ingress.
-	if err := r.reconcileIngress(ctx, &app); err != nil {
-		return r.setCondition(ctx, &app, metav1.ConditionFalse, "IngressFailed", err.Error())
+	// Reconcile the Access Applications of paths with their own rules.
+	if err := r.reconcilePathApps(ctx, &app, refs, report); err != nil {
+		return r.fail(ctx, &app, accessv1alpha1.ConditionPathAppsReady, "PathAppFailed", err)
 	}
-	logger.Info("reconciled tunnel ingress", "name", app.Name)
+	if n := len(app.Spec.Access.PathRules); n > 0 {
+		markCondition(&app, accessv1alpha1.ConditionPathAppsReady, metav1.ConditionTrue, "Reconciled",
+			fmt.Sprintf("%d path Access Applications are up to date", n))
+	} else {
+		markCondition(&app, accessv1alpha1.ConditionPathAppsReady, metav1.ConditionTrue, "NotRequired", "No path rules configured")
+	}
+	r.recordDrift(ctx, &app, report)
 
-	// 7. Reconcile direct OIDC Ingress (bypasses CF Access, app handles auth).
-	if app.Spec.NativeOIDC != nil && app.Spec.NativeOIDC.Ingress != nil {
-		if err := r.reconcileOIDCIngress(ctx, &app); err != nil {
-			return r.setCondition(ctx, &app, metav1.ConditionFalse, "OIDCIngressFailed", err.Error())
-		}
-		logger.Info("reconciled OIDC ingress", "name", app.Name+"-oidc")
+	// 6–7. Reconcile the tunnel route and, if configured, the direct OIDC
+	// route (bypasses CF Access, app handles auth).
+	if condType, reason, err := r.reconcileRoutes(ctx, &app); err != nil {
+		return r.fail(ctx, &app, condType, reason, err)
 	}
 
 	// 8. Update status.
@@ -231,68 +474,45 @@ func (r *SecuredApplicationReconciler) Reconcile(ctx context.Context, req ctrl.R
 	app.Status.ZitadelAppID = oidcApp.ID
 	app.Status.ClientID = oidcApp.ClientID
 	app.Status.AccessApplicationID = accessAppID
-	app.Status.AccessPolicyID = policy.ID
+	app.Status.AccessPolicyID = policyID
+	app.Status.AccessPolicyIDs = policyIDs
+	app.Status.ReusablePolicyIDs = reusableIDs
 	app.Status.BypassApplicationIDs = bypassIDs
+	app.Status.ObservedGeneration = app.Generation
 	app.Status.Ready = true
-	return r.setCondition(ctx, &app, metav1.ConditionTrue, "Reconciled", "All resources are up to date")
-}
-
-func (r *SecuredApplicationReconciler) reconcileZitadelApp(ctx context.Context, app *accessv1alpha1.SecuredApplication, projectID string) (*zitadel.App, string, error) {
-	// Construct redirect URI from host + path.
-	redirectHost := app.Spec.Host
-	redirectPath := "/callback"
-
-	config := zitadel.AppConfig{
-		Name:            app.Name,
-		ResponseTypes:   []string{"OIDC_RESPONSE_TYPE_CODE"},
-		GrantTypes:      []string{"OIDC_GRANT_TYPE_AUTHORIZATION_CODE"},
-		AppType:         "OIDC_APP_TYPE_WEB",
-		AuthMethodType:  "OIDC_AUTH_METHOD_TYPE_BASIC",
-		AccessTokenType: "OIDC_TOKEN_TYPE_BEARER",
+	result, err := r.setCondition(ctx, &app, metav1.ConditionTrue, "Reconciled", "All resources are up to date")
+	if err != nil {
+		return result, err
 	}
-
-	if app.Spec.NativeOIDC != nil {
-		if app.Spec.NativeOIDC.Ingress != nil {
-			redirectHost = app.Spec.NativeOIDC.Ingress.Host
-		}
-		if app.Spec.NativeOIDC.RedirectPath != "" {
-			redirectPath = app.Spec.NativeOIDC.RedirectPath
-		}
-		if app.Spec.NativeOIDC.PostLogoutRedirectPath != "" {
-			config.PostLogoutRedirectURIs = []string{fmt.Sprintf("https://%s%s", redirectHost, app.Spec.NativeOIDC.PostLogoutRedirectPath)}
-		}
-		if len(app.Spec.NativeOIDC.ResponseTypes) > 0 {
-			config.ResponseTypes = app.Spec.NativeOIDC.ResponseTypes
-		}
-		if len(app.Spec.NativeOIDC.GrantTypes) > 0 {
-			config.GrantTypes = app.Spec.NativeOIDC.GrantTypes
-		}
-		if app.Spec.NativeOIDC.AppType != "" {
-			config.AppType = app.Spec.NativeOIDC.AppType
-		}
-		if app.Spec.NativeOIDC.AuthMethodType != "" {
-			config.AuthMethodType = app.Spec.NativeOIDC.AuthMethodType
+	for _, next := range []time.Time{nextSecretRotation(&app), nextServiceTokenRenewal(&app)} {
+		if next.IsZero() {
+			continue
 		}
-		if app.Spec.NativeOIDC.AccessTokenType != "" {
-			config.AccessTokenType = app.Spec.NativeOIDC.AccessTokenType
+		untilNext := max(time.Until(next), time.Second)
+		if result.RequeueAfter == 0 || untilNext < result.RequeueAfter {
+			result.RequeueAfter = untilNext
 		}
-		config.DevMode = app.Spec.NativeOIDC.DevMode
-		config.IDTokenRoleAssertion = app.Spec.NativeOIDC.IDTokenRoleAssertion
-		config.IDTokenUserinfoAssertion = app.Spec.NativeOIDC.IDTokenUserinfoAssertion
-		config.AccessTokenRoleAssertion = app.Spec.NativeOIDC.AccessTokenRoleAssertion
 	}
+	return result, nil
+}
 
-	config.RedirectURIs = []string{fmt.Sprintf("https://%s%s", redirectHost, redirectPath)}
+func (r *SecuredApplicationReconciler) reconcileZitadelApp(ctx context.Context, app *accessv1alpha1.SecuredApplication, projectID string) (*zitadel.App, string, error) {
+	config := builder.ZitadelAppConfig(app)
 
-	// Update existing app.
+	// Update existing app. If it was deleted in Zitadel, fall through to
+	// re-adoption or creation.
 	if app.Status.ZitadelAppID != "" {
-		if err := r.Zitadel.UpdateApp(ctx, projectID, app.Status.ZitadelAppID, config); err != nil {
+		err := r.Zitadel.UpdateApp(ctx, projectID, app.Status.ZitadelAppID, config)
+		if err == nil {
+			return &zitadel.App{
+				ID:       app.Status.ZitadelAppID,
+				ClientID: app.Status.ClientID,
+			}, "", nil
+		}
+		if !zitadel.IsNotFound(err) {
 			return nil, "", err
 		}
-		return &zitadel.App{
-			ID:       app.Status.ZitadelAppID,
-			ClientID: app.Status.ClientID,
-		}, "", nil
+		log.FromContext(ctx).Info("Zitadel app no longer exists, recreating", "appId", app.Status.ZitadelAppID)
 	}
 
 	// Try to find by name (re-adoption).
@@ -304,6 +524,8 @@ func (r *SecuredApplicationReconciler) reconcileZitadelApp(ctx context.Context,
 		if err := r.Zitadel.UpdateApp(ctx, projectID, existing.ID, config); err != nil {
 			return nil, "", err
 		}
+		metrics.RecordAdoption(metrics.KindZitadelApp)
+		r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "AdoptedZitadelApp", "Adopt", "Adopted existing Zitadel OIDC app %s", existing.ID)
 		return existing, "", nil
 	}
 
@@ -312,18 +534,63 @@ func (r *SecuredApplicationReconciler) reconcileZitadelApp(ctx context.Context,
 	if err != nil {
 		return nil, "", err
 	}
+	r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "CreatedZitadelApp", "Create", "Created Zitadel OIDC app %s with client ID %s", created.ID, created.ClientID)
 	return created, created.ClientSecret, nil
 }
 
-func (r *SecuredApplicationReconciler) writeCredentialSecret(ctx context.Context, app *accessv1alpha1.SecuredApplication, clientID, clientSecret string) error {
-	secretName := app.Name + "-oidc"
+// credentialSecretName returns the name of the Secret holding the OIDC client credentials.
+func credentialSecretName(app *accessv1alpha1.SecuredApplication) string {
 	if app.Spec.NativeOIDC != nil && app.Spec.NativeOIDC.ClientSecretRef != "" {
-		secretName = app.Spec.NativeOIDC.ClientSecretRef
+		return app.Spec.NativeOIDC.ClientSecretRef
+	}
+	return app.Name + "-oidc"
+}
+
+// hasAccessPolicies reports whether app defines at least one Access policy.
+// An Access Application without policies would deny everyone.
+func hasAccessPolicies(app *accessv1alpha1.SecuredApplication) bool {
+	access := app.Spec.Access
+	return builder.HasAllowPolicy(app) || len(access.Policies) > 0 || len(access.PolicyRefs) > 0 || len(access.ServiceTokens) > 0
+}
+
+// requestedRoles returns the roles of spec.access and its path rules,
+// without duplicates.
+func requestedRoles(app *accessv1alpha1.SecuredApplication) []string {
+	roles := slices.Clone(app.Spec.Access.Roles)
+	for _, rule := range app.Spec.Access.PathRules {
+		for _, role := range rule.Roles {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
 	}
+	return roles
+}
+
+// usesClientSecret reports whether the Zitadel app authenticates with a
+// client secret. Public clients (auth method NONE) never get one.
+func usesClientSecret(app *accessv1alpha1.SecuredApplication) bool {
+	return app.Spec.NativeOIDC == nil || app.Spec.NativeOIDC.AuthMethodType != "OIDC_AUTH_METHOD_TYPE_NONE"
+}
+
+// credentialSecretMissing reports whether the credential Secret is absent or
+// lacks the current client ID and a client secret.
+func (r *SecuredApplicationReconciler) credentialSecretMissing(ctx context.Context, app *accessv1alpha1.SecuredApplication, clientID string) (bool, error) {
+	var secret corev1.Secret
+	key := client.ObjectKey{Namespace: app.Namespace, Name: credentialSecretName(app)}
+	if err := r.Get(ctx, key, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return string(secret.Data["clientId"]) != clientID || len(secret.Data["clientSecret"]) == 0, nil
+}
 
+func (r *SecuredApplicationReconciler) writeCredentialSecret(ctx context.Context, app *accessv1alpha1.SecuredApplication, clientID, clientSecret string) error {
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretName,
+			Name:      credentialSecretName(app),
 			Namespace: app.Namespace,
 		},
 	}
@@ -341,138 +608,178 @@ func (r *SecuredApplicationReconciler) writeCredentialSecret(ctx context.Context
 	return err
 }
 
-func (r *SecuredApplicationReconciler) reconcileIngress(ctx context.Context, app *accessv1alpha1.SecuredApplication) error {
-	ingress := &networkingv1.Ingress{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      app.Name,
-			Namespace: app.Namespace,
-		},
+// secretRotationDue reports whether the client secret should be regenerated,
+// either because the rotate-secret annotation carries a value that has not
+// been acted on yet or because the rotation interval has elapsed.
+func secretRotationDue(app *accessv1alpha1.SecuredApplication, now time.Time) bool {
+	if request := app.Annotations[rotateSecretAnnotation]; request != "" && request != app.Status.LastRotationRequest {
+		return true
 	}
+	next := nextSecretRotation(app)
+	return !next.IsZero() && !now.Before(next)
+}
 
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, ingress, func() error {
-		if err := controllerutil.SetControllerReference(app, ingress, r.Scheme); err != nil {
-			return err
+// nextSecretRotation returns when the rotation interval next elapses, or the
+// zero time if scheduled rotation is disabled.
+func nextSecretRotation(app *accessv1alpha1.SecuredApplication) time.Time {
+	if app.Spec.NativeOIDC == nil || app.Spec.NativeOIDC.SecretRotationInterval == nil {
+		return time.Time{}
+	}
+	interval := app.Spec.NativeOIDC.SecretRotationInterval.Duration
+	if interval <= 0 {
+		return time.Time{}
+	}
+	last := app.CreationTimestamp.Time
+	if app.Status.LastSecretRotation != nil {
+		last = app.Status.LastSecretRotation.Time
+	}
+	return last.Add(interval)
+}
+
+// reconcileRoutes creates the Ingresses, HTTPRoutes or tunnel rules for the
+// tunnel and native OIDC hosts according to spec.routing, and removes those
+// left over from a previous mode. On failure it returns the condition reason.
+func (r *SecuredApplicationReconciler) reconcileRoutes(ctx context.Context, app *accessv1alpha1.SecuredApplication) (condType, reason string, err error) {
+	logger := log.FromContext(ctx)
+	wantOIDC := app.Spec.NativeOIDC != nil && app.Spec.NativeOIDC.Ingress != nil
+
+	if routingMode(app) == routingModeHTTPRoute {
+		if !r.Config.EnableGatewayAPI {
+			return accessv1alpha1.ConditionIngressReady, "GatewayAPIDisabled", fmt.Errorf("routing mode HTTPRoute requires the operator to run with --enable-gateway-api")
+		}
+		if app.Spec.Routing.Gateway == nil {
+			return accessv1alpha1.ConditionIngressReady, "InvalidRouting", fmt.Errorf("routing.gateway is required in HTTPRoute mode")
 		}
 
-		className := "cloudflare-tunnel"
-		if app.Spec.Ingress != nil && app.Spec.Ingress.ClassName != "" {
-			className = app.Spec.Ingress.ClassName
+		tunnel := builder.TunnelHTTPRoute(app)
+		if _, err := r.reconcileHTTPRoute(ctx, app, tunnel); err != nil {
+			return accessv1alpha1.ConditionIngressReady, "HTTPRouteFailed", err
 		}
-		ingress.Spec.IngressClassName = &className
+		logger.Info("reconciled tunnel HTTPRoute", "name", tunnel.GetName())
+		markCondition(app, accessv1alpha1.ConditionIngressReady, metav1.ConditionTrue, "Reconciled", fmt.Sprintf("HTTPRoute %s is up to date", tunnel.GetName()))
 
-		annotations := make(map[string]string)
-		if app.Spec.Ingress != nil {
-			for k, v := range app.Spec.Ingress.Annotations {
-				annotations[k] = v
+		if wantOIDC {
+			oidc := builder.OIDCHTTPRoute(app)
+			if _, err := r.reconcileHTTPRoute(ctx, app, oidc); err != nil {
+				return accessv1alpha1.ConditionOIDCIngressReady, "OIDCHTTPRouteFailed", err
 			}
+			logger.Info("reconciled OIDC HTTPRoute", "name", oidc.GetName())
+			markCondition(app, accessv1alpha1.ConditionOIDCIngressReady, metav1.ConditionTrue, "Reconciled", fmt.Sprintf("HTTPRoute %s is up to date", oidc.GetName()))
+		} else {
+			if err := r.deleteIfOwned(ctx, app, newHTTPRoute(app.Name+"-oidc", app.Namespace)); err != nil {
+				return accessv1alpha1.ConditionOIDCIngressReady, "OIDCHTTPRouteFailed", err
+			}
+			markCondition(app, accessv1alpha1.ConditionOIDCIngressReady, metav1.ConditionTrue, "NotRequired", "nativeOIDC.ingress is not configured")
 		}
-		if app.Spec.Backend.Protocol != "" {
-			annotations[cfBackendProtocolAnnotation] = app.Spec.Backend.Protocol
-		}
-		ingress.Annotations = annotations
-
-		pathType := networkingv1.PathTypePrefix
-		if app.Spec.Ingress != nil && app.Spec.Ingress.PathType != "" {
-			pathType = networkingv1.PathType(app.Spec.Ingress.PathType)
-		}
-		path := "/"
-		if app.Spec.Ingress != nil && app.Spec.Ingress.Path != "" {
-			path = app.Spec.Ingress.Path
-		}
-
-		ingress.Spec.Rules = []networkingv1.IngressRule{
-			{
-				Host: app.Spec.Host,
-				IngressRuleValue: networkingv1.IngressRuleValue{
-					HTTP: &networkingv1.HTTPIngressRuleValue{
-						Paths: []networkingv1.HTTPIngressPath{
-							{
-								Path:     path,
-								PathType: &pathType,
-								Backend: networkingv1.IngressBackend{
-									Service: &networkingv1.IngressServiceBackend{
-										Name: app.Spec.Backend.ServiceName,
-										Port: networkingv1.ServiceBackendPort{
-											Number: app.Spec.Backend.ServicePort,
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+
+		// Remove Ingresses and tunnel routes from a previous configuration.
+		for _, name := range []string{app.Name, app.Name + "-oidc"} {
+			ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: app.Namespace}}
+			if err := r.deleteIfOwned(ctx, app, ingress); err != nil {
+				return accessv1alpha1.ConditionIngressReady, "IngressFailed", err
+			}
+		}
+		if err := r.deleteTunnelRoutes(ctx, app); err != nil {
+			return accessv1alpha1.ConditionIngressReady, "TunnelRouteFailed", err
 		}
+		return "", "", nil
+	}
 
-		return nil
-	})
+	if wantOIDC && app.Spec.NativeOIDC.Ingress.ClassName == "" {
+		return accessv1alpha1.ConditionOIDCIngressReady, "InvalidRouting", fmt.Errorf("nativeOIDC.ingress.className is required in %s routing mode", routingMode(app))
+	}
+	if routingMode(app) == routingModeTunnel {
+		if r.tunnelName(app) == "" {
+			return accessv1alpha1.ConditionIngressReady, "InvalidRouting", fmt.Errorf("routing mode Tunnel requires routing.tunnelName or the operator's --cloudflare-tunnel-name")
+		}
+		tunnel, err := r.findTunnel(ctx, app)
+		if err != nil {
+			return accessv1alpha1.ConditionIngressReady, "TunnelNotFound", err
+		}
+		if err := r.reconcileTunnelRoutes(ctx, app, tunnel); err != nil {
+			var conflict *cfclient.DNSConflictError
+			if errors.As(err, &conflict) {
+				return accessv1alpha1.ConditionIngressReady, "DNSRecordConflict", err
+			}
+			return accessv1alpha1.ConditionIngressReady, "TunnelRouteFailed", err
+		}
+		hosts := strings.Join(builder.Hosts(app), ", ")
+		logger.Info("reconciled tunnel routes", "hosts", hosts, "tunnel", tunnel.Name)
+		markCondition(app, accessv1alpha1.ConditionIngressReady, metav1.ConditionTrue, "Reconciled", fmt.Sprintf("%s routed through tunnel %s", hosts, tunnel.Name))
 
-	return err
+		// Remove the tunnel Ingress from a previous Ingress-mode configuration.
+		ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: app.Namespace}}
+		if err := r.deleteIfOwned(ctx, app, ingress); err != nil {
+			return accessv1alpha1.ConditionIngressReady, "IngressFailed", err
+		}
+	} else {
+		if _, err := r.reconcileIngress(ctx, app); err != nil {
+			return accessv1alpha1.ConditionIngressReady, "IngressFailed", err
+		}
+		logger.Info("reconciled tunnel ingress", "name", app.Name)
+		markCondition(app, accessv1alpha1.ConditionIngressReady, metav1.ConditionTrue, "Reconciled", fmt.Sprintf("Ingress %s is up to date", app.Name))
+
+		// Remove tunnel routes from a previous Tunnel-mode configuration.
+		if err := r.deleteTunnelRoutes(ctx, app); err != nil {
+			return accessv1alpha1.ConditionIngressReady, "TunnelRouteFailed", err
+		}
+	}
+
+	if wantOIDC {
+		if _, err := r.reconcileOIDCIngress(ctx, app); err != nil {
+			return accessv1alpha1.ConditionOIDCIngressReady, "OIDCIngressFailed", err
+		}
+		logger.Info("reconciled OIDC ingress", "name", app.Name+"-oidc")
+		markCondition(app, accessv1alpha1.ConditionOIDCIngressReady, metav1.ConditionTrue, "Reconciled", fmt.Sprintf("Ingress %s is up to date", app.Name+"-oidc"))
+	} else {
+		ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: app.Name + "-oidc", Namespace: app.Namespace}}
+		if err := r.deleteIfOwned(ctx, app, ingress); err != nil {
+			return accessv1alpha1.ConditionOIDCIngressReady, "OIDCIngressFailed", err
+		}
+		markCondition(app, accessv1alpha1.ConditionOIDCIngressReady, metav1.ConditionTrue, "NotRequired", "nativeOIDC.ingress is not configured")
+	}
+
+	// Remove HTTPRoutes from a previous HTTPRoute-mode configuration.
+	if r.Config.EnableGatewayAPI {
+		for _, name := range []string{app.Name, app.Name + "-oidc"} {
+			if err := r.deleteIfOwned(ctx, app, newHTTPRoute(name, app.Namespace)); err != nil {
+				return accessv1alpha1.ConditionIngressReady, "HTTPRouteFailed", err
+			}
+		}
+	}
+	return "", "", nil
 }
 
-func (r *SecuredApplicationReconciler) reconcileOIDCIngress(ctx context.Context, app *accessv1alpha1.SecuredApplication) error {
-	oidcIngress := app.Spec.NativeOIDC.Ingress
+func (r *SecuredApplicationReconciler) reconcileIngress(ctx context.Context, app *accessv1alpha1.SecuredApplication) (controllerutil.OperationResult, error) {
+	return r.applyIngress(ctx, app, builder.Ingress(app))
+}
 
+func (r *SecuredApplicationReconciler) reconcileOIDCIngress(ctx context.Context, app *accessv1alpha1.SecuredApplication) (controllerutil.OperationResult, error) {
+	return r.applyIngress(ctx, app, builder.OIDCIngress(app))
+}
+
+// applyIngress creates or updates the Ingress named like desired, owned by
+// app. Only the fields the operator manages are overwritten.
+func (r *SecuredApplicationReconciler) applyIngress(ctx context.Context, app *accessv1alpha1.SecuredApplication, desired *networkingv1.Ingress) (controllerutil.OperationResult, error) {
 	ingress := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      app.Name + "-oidc",
-			Namespace: app.Namespace,
+			Name:      desired.Name,
+			Namespace: desired.Namespace,
 		},
 	}
 
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, ingress, func() error {
+	return controllerutil.CreateOrUpdate(ctx, r.Client, ingress, func() error {
 		if err := controllerutil.SetControllerReference(app, ingress, r.Scheme); err != nil {
 			return err
 		}
-
-		ingress.Spec.IngressClassName = &oidcIngress.ClassName
-
-		annotations := make(map[string]string)
-		for k, v := range oidcIngress.Annotations {
-			annotations[k] = v
-		}
-		ingress.Annotations = annotations
-
-		pathType := networkingv1.PathTypePrefix
-		if oidcIngress.PathType != "" {
-			pathType = networkingv1.PathType(oidcIngress.PathType)
-		}
-		path := "/"
-		if oidcIngress.Path != "" {
-			path = oidcIngress.Path
-		}
-
-		ingress.Spec.Rules = []networkingv1.IngressRule{
-			{
-				Host: oidcIngress.Host,
-				IngressRuleValue: networkingv1.IngressRuleValue{
-					HTTP: &networkingv1.HTTPIngressRuleValue{
-						Paths: []networkingv1.HTTPIngressPath{
-							{
-								Path:     path,
-								PathType: &pathType,
-								Backend: networkingv1.IngressBackend{
-									Service: &networkingv1.IngressServiceBackend{
-										Name: app.Spec.Backend.ServiceName,
-										Port: networkingv1.ServiceBackendPort{
-											Number: app.Spec.Backend.ServicePort,
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		}
-
+		ingress.Annotations = desired.Annotations
+		ingress.Spec.IngressClassName = desired.Spec.IngressClassName
+		ingress.Spec.Rules = desired.Spec.Rules
 		return nil
 	})
-
-	return err
 }
 
-func (r *SecuredApplicationReconciler) reconcileBypassApps(ctx context.Context, app *accessv1alpha1.SecuredApplication) (map[string]string, error) {
+func (r *SecuredApplicationReconciler) reconcileBypassApps(ctx context.Context, app *accessv1alpha1.SecuredApplication, report *driftReport) (map[string]string, error) {
 	logger := log.FromContext(ctx)
 	desired := make(map[string]bool, len(app.Spec.Access.BypassPaths))
 	for _, p := range app.Spec.Access.BypassPaths {
@@ -488,53 +795,176 @@ func (r *SecuredApplicationReconciler) reconcileBypassApps(ctx context.Context,
 			if err := r.Cloudflare.DeleteAccessApp(ctx, appID); err != nil {
 				return nil, fmt.Errorf("delete stale bypass app for %q: %w", path, err)
 			}
+			r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "DeletedBypassApp", "Delete", "Deleted bypass Access Application %s for %s", appID, path)
 		}
 	}
 
-	// Create or keep bypass apps for desired paths.
-	for _, path := range app.Spec.Access.BypassPaths {
-		if existingID, ok := app.Status.BypassApplicationIDs[path]; ok {
-			result[path] = existingID
+	// Create, repair or keep bypass apps for desired paths. Their domains
+	// change with spec.hosts.
+	for _, bypass := range builder.BypassApps(app) {
+		if existingID, ok := app.Status.BypassApplicationIDs[bypass.Path]; ok {
+			drift, err := r.Cloudflare.DetectBypassDrift(ctx, existingID, bypass.Name, bypass.Domains)
+			if err != nil {
+				return nil, fmt.Errorf("detect drift of bypass app for %q: %w", bypass.Path, err)
+			}
+			report.add(drift)
+			if !drift.AppMissing && drift.Drifted() {
+				err := r.Cloudflare.UpdateBypassApp(ctx, existingID, bypass.Name, bypass.Domains)
+				switch {
+				case cfclient.IsNotFound(err):
+					drift.AppMissing = true
+				case err != nil:
+					return nil, fmt.Errorf("update bypass app for %q: %w", bypass.Path, err)
+				default:
+					r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "UpdatedBypassApp", "Update", "Updated bypass Access Application %s for %s", existingID, strings.Join(bypass.Domains, ", "))
+				}
+			}
+			if !drift.AppMissing {
+				result[bypass.Path] = existingID
+				continue
+			}
+			logger.Info("bypass Access Application no longer exists, recreating", "path", bypass.Path, "appId", existingID)
+		}
+
+		logger.Info("creating bypass Access Application", "domains", bypass.Domains)
+		created, err := r.Cloudflare.CreateBypassApp(ctx, bypass.Name, bypass.Domains)
+		if err != nil {
+			return nil, fmt.Errorf("create bypass app for %q: %w", bypass.Path, err)
+		}
+		result[bypass.Path] = created.ID
+		r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "CreatedBypassApp", "Create", "Created bypass Access Application %s for %s", created.ID, strings.Join(bypass.Domains, ", "))
+	}
+
+	return result, nil
+}
+
+// reconcileAccessPolicies creates or updates the policies in
+// spec.access.policies on the Access Application and deletes the ones that
+// were removed from the spec. It returns the policy IDs by name.
+func (r *SecuredApplicationReconciler) reconcileAccessPolicies(ctx context.Context, app *accessv1alpha1.SecuredApplication, refs builder.Refs, accessAppID string, report *driftReport) (map[string]string, error) {
+	logger := log.FromContext(ctx)
+	desired := builder.AccessPolicies(app, refs)
+	wanted := make(map[string]bool, len(desired))
+	for _, policy := range desired {
+		wanted[policy.Name] = true
+	}
+
+	// Delete policies that are no longer in the spec.
+	for name, policyID := range app.Status.AccessPolicyIDs {
+		if !wanted[name] {
+			logger.Info("removing stale Access policy", "name", name, "policyId", policyID)
+			if err := r.Cloudflare.DeleteAccessPolicy(ctx, accessAppID, policyID); err != nil {
+				return nil, fmt.Errorf("delete stale policy %q: %w", name, err)
+			}
+			r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "DeletedAccessPolicy", "Delete", "Deleted Access policy %s (%s)", policyID, name)
+		}
+	}
+
+	result := make(map[string]string, len(desired))
+	for _, policy := range desired {
+		policyID := app.Status.AccessPolicyIDs[policy.Name]
+		drift, err := r.Cloudflare.DetectPolicyDrift(ctx, accessAppID, policyID, policy)
+		if err != nil {
+			return nil, fmt.Errorf("detect drift of policy %q: %w", policy.Name, err)
+		}
+		if !drift.Drifted() {
+			result[policy.Name] = policyID
 			continue
 		}
+		if policyID != "" {
+			report.add(drift)
+		}
+		if drift.PolicyMissing {
+			policyID = ""
+		}
 
-		domain := app.Spec.Host + path
-		name := fmt.Sprintf("%s-bypass-%s", app.Name, path)
-		logger.Info("creating bypass Access Application", "domain", domain)
-		created, err := r.Cloudflare.CreateBypassApp(ctx, name, domain)
+		upserted, err := r.Cloudflare.UpsertAccessPolicy(ctx, accessAppID, policyID, policy)
 		if err != nil {
-			return nil, fmt.Errorf("create bypass app for %q: %w", path, err)
+			return nil, fmt.Errorf("upsert policy %q: %w", policy.Name, err)
 		}
-		result[path] = created.ID
+		result[policy.Name] = upserted.ID
+		r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "UpdatedAccessPolicy", "Update", "Updated Access policy %s (%s)", upserted.ID, policy.Name)
 	}
 
 	return result, nil
 }
 
-func (r *SecuredApplicationReconciler) setCondition(ctx context.Context, app *accessv1alpha1.SecuredApplication, status metav1.ConditionStatus, reason, message string) (ctrl.Result, error) {
+// driftReport collects the drift found in the Access Applications and
+// policies of one reconcile, so that the Drifted condition covers all of
+// them rather than the last one compared.
+type driftReport struct {
+	app     *accessv1alpha1.SecuredApplication
+	changes []string
+}
+
+// add records the differences of one comparison. Differences caused by a
+// spec change are not drift — they are simply being applied. Drift is
+// marked as detected right away, so that it stays visible if the repair
+// fails; recordDrift marks it repaired once every update went through.
+func (d *driftReport) add(drift *cfclient.Drift) {
+	if !drift.Drifted() || d.app.Status.ObservedGeneration != d.app.Generation {
+		return
+	}
+	d.changes = append(d.changes, drift.Changes...)
+	markCondition(d.app, accessv1alpha1.ConditionDrifted, metav1.ConditionTrue, "DriftDetected", strings.Join(d.changes, "; "))
+}
+
+// recordDrift sets the Drifted condition after the Access Applications and
+// policies were updated, and emits an Event for the drift that was repaired.
+func (r *SecuredApplicationReconciler) recordDrift(ctx context.Context, app *accessv1alpha1.SecuredApplication, report *driftReport) {
+	if len(report.changes) > 0 {
+		log.FromContext(ctx).Info("repaired drift in Cloudflare", "changes", report.changes)
+		r.Recorder.Eventf(app, nil, corev1.EventTypeWarning, "DriftRepaired", "Update", "Reverted changes made in Cloudflare: %s", strings.Join(report.changes, "; "))
+		markCondition(app, accessv1alpha1.ConditionDrifted, metav1.ConditionTrue, "DriftRepaired", strings.Join(report.changes, "; "))
+		return
+	}
+	markCondition(app, accessv1alpha1.ConditionDrifted, metav1.ConditionFalse, "InSync", "Access Applications and policies match the spec")
+}
+
+// markCondition sets a condition for the current generation. The status is
+// only persisted by the next setCondition.
+func markCondition(app *accessv1alpha1.SecuredApplication, condType string, status metav1.ConditionStatus, reason, message string) {
 	meta.SetStatusCondition(&app.Status.Conditions, metav1.Condition{
-		Type:               "Ready",
+		Type:               condType,
 		Status:             status,
+		ObservedGeneration: app.Generation,
 		Reason:             reason,
 		Message:            message,
-		LastTransitionTime: metav1.Now(),
 	})
+}
+
+// setCondition sets the aggregated Ready condition and writes the status.
+func (r *SecuredApplicationReconciler) setCondition(ctx context.Context, app *accessv1alpha1.SecuredApplication, status metav1.ConditionStatus, reason, message string) (ctrl.Result, error) {
 	if status != metav1.ConditionTrue {
 		app.Status.Ready = false
 	}
-	if err := r.Status().Update(ctx, app); err != nil {
-		return ctrl.Result{}, err
-	}
-	if status != metav1.ConditionTrue {
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
-	}
-	return ctrl.Result{}, nil
+	return r.statusWriter().setReady(ctx, app, status, reason, message)
+}
+
+func (r *SecuredApplicationReconciler) statusWriter() statusWriter {
+	return statusWriter{client: r.Client, recorder: r.Recorder, resync: r.Config.ResyncInterval}
 }
 
 func (r *SecuredApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &accessv1alpha1.SecuredApplication{}, hosts.IndexField, hosts.Index); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &accessv1alpha1.SecuredApplication{}, PolicyRefIndexField, indexPolicyRefs); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &accessv1alpha1.SecuredApplication{}, GroupRefIndexField, indexAppGroupRefs); err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&accessv1alpha1.SecuredApplication{}).
 		Owns(&networkingv1.Ingress{}).
 		Owns(&corev1.Secret{}).
-		Complete(r)
+		Watches(&accessv1alpha1.SecuredApplication{}, handler.EnqueueRequestsFromMapFunc(r.appsSharingHosts)).
+		Watches(&accessv1alpha1.AccessPolicy{}, handler.EnqueueRequestsFromMapFunc(r.appsUsingPolicy)).
+		Watches(&accessv1alpha1.AccessGroup{}, handler.EnqueueRequestsFromMapFunc(r.appsUsingGroup))
+	if r.Config.EnableGatewayAPI {
+		b = b.Owns(newHTTPRoute("", ""))
+	}
+	return b.Complete(r)
 }
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// Keys of the service token Secret, named after the request headers that
+// carry them.
+const (
+	serviceTokenClientIDKey     = "CF-Access-Client-Id"
+	serviceTokenClientSecretKey = "CF-Access-Client-Secret"
+)
+
+func serviceTokenSecretName(app *accessv1alpha1.SecuredApplication, token *accessv1alpha1.ServiceTokenConfig) string {
+	if token.SecretName != "" {
+		return token.SecretName
+	}
+	return app.Name + "-" + token.Name + "-service-token"
+}
+
+// serviceTokenRenewal returns when a token expiring at expiresAt is
+// refreshed and rotated: once less than a tenth of its duration remains.
+func serviceTokenRenewal(app *accessv1alpha1.SecuredApplication, token *accessv1alpha1.ServiceTokenConfig, expiresAt time.Time) time.Time {
+	_, duration := builder.ServiceToken(app, token)
+	return expiresAt.Add(-duration / 10)
+}
+
+// nextServiceTokenRenewal returns the earliest renewal of the tokens in
+// status, or the zero time if there are none.
+func nextServiceTokenRenewal(app *accessv1alpha1.SecuredApplication) time.Time {
+	var next time.Time
+	for i := range app.Spec.Access.ServiceTokens {
+		token := &app.Spec.Access.ServiceTokens[i]
+		status, ok := app.Status.ServiceTokens[token.Name]
+		if !ok || status.ExpiresAt == nil {
+			continue
+		}
+		if renewal := serviceTokenRenewal(app, token, status.ExpiresAt.Time); next.IsZero() || renewal.Before(next) {
+			next = renewal
+		}
+	}
+	return next
+}
+
+// serviceTokenIDs returns the Cloudflare IDs of the tokens in status by name.
+func serviceTokenIDs(app *accessv1alpha1.SecuredApplication) map[string]string {
+	ids := make(map[string]string, len(app.Status.ServiceTokens))
+	for name, status := range app.Status.ServiceTokens {
+		ids[name] = status.ID
+	}
+	return ids
+}
+
+// reconcileServiceTokens creates the tokens in spec.access.serviceTokens,
+// writes their credentials to Secrets and refreshes and rotates them before
+// they expire. Progress is recorded in app.Status.ServiceTokens right away,
+// so that a later failure does not orphan a created token. Tokens removed
+// from the spec are left to deleteStaleServiceTokens.
+func (r *SecuredApplicationReconciler) reconcileServiceTokens(ctx context.Context, app *accessv1alpha1.SecuredApplication) error {
+	logger := log.FromContext(ctx)
+	now := time.Now()
+
+	for i := range app.Spec.Access.ServiceTokens {
+		token := &app.Spec.Access.ServiceTokens[i]
+		name, duration := builder.ServiceToken(app, token)
+		secretName := serviceTokenSecretName(app, token)
+		status := app.Status.ServiceTokens[token.Name]
+
+		var live *cfclient.ServiceToken
+		if status.ID != "" {
+			var err error
+			live, err = r.Cloudflare.GetServiceToken(ctx, status.ID)
+			if err != nil {
+				return fmt.Errorf("get service token %q: %w", token.Name, err)
+			}
+			if live == nil {
+				logger.Info("service token no longer exists, recreating", "name", token.Name, "tokenId", status.ID)
+			}
+		}
+
+		var clientSecret string
+		if live == nil {
+			created, err := r.Cloudflare.CreateServiceToken(ctx, name, duration.String())
+			if err != nil {
+				return fmt.Errorf("create service token %q: %w", token.Name, err)
+			}
+			live = created
+			clientSecret = created.ClientSecret
+			logger.Info("created service token", "name", token.Name, "tokenId", created.ID)
+			r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "CreatedServiceToken", "Create", "Created service token %s (%s)", created.ID, token.Name)
+		} else {
+			if live.Name != name || (live.Duration != "" && !sameDuration(live.Duration, duration)) {
+				if err := r.Cloudflare.UpdateServiceToken(ctx, live.ID, name, duration.String()); err != nil {
+					return fmt.Errorf("update service token %q: %w", token.Name, err)
+				}
+			}
+
+			renew := !live.ExpiresAt.IsZero() && !now.Before(serviceTokenRenewal(app, token, live.ExpiresAt))
+			if renew {
+				refreshed, err := r.Cloudflare.RefreshServiceToken(ctx, live.ID)
+				if err != nil {
+					return fmt.Errorf("refresh service token %q: %w", token.Name, err)
+				}
+				if !refreshed.ExpiresAt.IsZero() {
+					live.ExpiresAt = refreshed.ExpiresAt
+				}
+			}
+
+			// Cloudflare never returns an existing client secret, so a
+			// missing Secret can only be repopulated by rotating.
+			missing, err := r.serviceTokenSecretMissing(ctx, app, secretName, live.ClientID)
+			if err != nil {
+				return fmt.Errorf("get service token Secret %s: %w", secretName, err)
+			}
+			if renew || missing {
+				rotated, err := r.Cloudflare.RotateServiceToken(ctx, live.ID)
+				if err != nil {
+					return fmt.Errorf("rotate service token %q: %w", token.Name, err)
+				}
+				clientSecret = rotated.ClientSecret
+				logger.Info("rotated service token", "name", token.Name, "tokenId", live.ID, "secretMissing", missing)
+				r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "RotatedServiceToken", "Rotate", "Rotated service token %s (%s)", live.ID, token.Name)
+			}
+		}
+
+		if clientSecret != "" {
+			if err := r.writeServiceTokenSecret(ctx, app, secretName, live.ClientID, clientSecret); err != nil {
+				return fmt.Errorf("write service token Secret %s: %w", secretName, err)
+			}
+		}
+		if status.SecretName != "" && status.SecretName != secretName {
+			old := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: status.SecretName, Namespace: app.Namespace}}
+			if err := r.deleteIfOwned(ctx, app, old); err != nil {
+				return fmt.Errorf("delete previous service token Secret %s: %w", status.SecretName, err)
+			}
+		}
+
+		status = accessv1alpha1.ServiceTokenStatus{ID: live.ID, ClientID: live.ClientID, SecretName: secretName}
+		if !live.ExpiresAt.IsZero() {
+			status.ExpiresAt = &metav1.Time{Time: live.ExpiresAt}
+		}
+		if app.Status.ServiceTokens == nil {
+			app.Status.ServiceTokens = make(map[string]accessv1alpha1.ServiceTokenStatus)
+		}
+		app.Status.ServiceTokens[token.Name] = status
+	}
+	return nil
+}
+
+// deleteStaleServiceTokens deletes the tokens in status that were removed
+// from the spec, along with their Secrets. It runs once the service token
+// policy no longer admits them.
+func (r *SecuredApplicationReconciler) deleteStaleServiceTokens(ctx context.Context, app *accessv1alpha1.SecuredApplication) error {
+	wanted := make(map[string]bool, len(app.Spec.Access.ServiceTokens))
+	for _, token := range app.Spec.Access.ServiceTokens {
+		wanted[token.Name] = true
+	}
+	for name, status := range app.Status.ServiceTokens {
+		if wanted[name] {
+			continue
+		}
+		log.FromContext(ctx).Info("removing stale service token", "name", name, "tokenId", status.ID)
+		if err := r.Cloudflare.DeleteServiceToken(ctx, status.ID); err != nil {
+			return fmt.Errorf("delete stale service token %q: %w", name, err)
+		}
+		if status.SecretName != "" {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: status.SecretName, Namespace: app.Namespace}}
+			if err := r.deleteIfOwned(ctx, app, secret); err != nil {
+				return fmt.Errorf("delete Secret of stale service token %q: %w", name, err)
+			}
+		}
+		delete(app.Status.ServiceTokens, name)
+		r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "DeletedServiceToken", "Delete", "Deleted service token %s (%s)", status.ID, name)
+	}
+	return nil
+}
+
+// serviceTokenSecretMissing reports whether the Secret is absent or lacks the
+// current client ID and a client secret.
+func (r *SecuredApplicationReconciler) serviceTokenSecretMissing(ctx context.Context, app *accessv1alpha1.SecuredApplication, name, clientID string) (bool, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: app.Namespace, Name: name}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return string(secret.Data[serviceTokenClientIDKey]) != clientID || len(secret.Data[serviceTokenClientSecretKey]) == 0, nil
+}
+
+func (r *SecuredApplicationReconciler) writeServiceTokenSecret(ctx context.Context, app *accessv1alpha1.SecuredApplication, name, clientID, clientSecret string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: app.Namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := controllerutil.SetControllerReference(app, secret, r.Scheme); err != nil {
+			return err
+		}
+		secret.StringData = map[string]string{
+			serviceTokenClientIDKey:     clientID,
+			serviceTokenClientSecretKey: clientSecret,
+		}
+		return nil
+	})
+	return err
+}
+
+// sameDuration reports whether a duration returned by Cloudflare (e.g.
+// "8760h") equals want.
+func sameDuration(live string, want time.Duration) bool {
+	d, err := time.ParseDuration(live)
+	return err == nil && d == want
+}
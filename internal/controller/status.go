@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+)
+
+// statusObject is an object whose status carries conditions.
+type statusObject interface {
+	client.Object
+	StatusConditions() *[]metav1.Condition
+}
+
+// statusWriter sets the Ready and Stalled conditions and writes the status
+// the same way for every kind the operator reconciles.
+type statusWriter struct {
+	client   client.Client
+	recorder events.EventRecorder
+	resync   time.Duration
+}
+
+// fail records a failed reconcile on the Ready condition and requeues
+// according to classifyError. Terminal failures additionally set the
+// Stalled condition and are only requeued for the periodic resync.
+func (w statusWriter) fail(ctx context.Context, obj statusObject, reason string, err error) (ctrl.Result, error) {
+	policy := classifyError(err)
+	if policy.terminal {
+		meta.SetStatusCondition(obj.StatusConditions(), metav1.Condition{
+			Type:               accessv1alpha1.ConditionStalled,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: obj.GetGeneration(),
+			Reason:             reason,
+			Message:            "The request was rejected and will only be retried on the next resync or when the spec changes: " + err.Error(),
+		})
+	} else {
+		meta.RemoveStatusCondition(obj.StatusConditions(), accessv1alpha1.ConditionStalled)
+	}
+
+	if _, updateErr := w.setReady(ctx, obj, metav1.ConditionFalse, reason, err.Error()); updateErr != nil {
+		return ctrl.Result{}, updateErr
+	}
+	if policy.terminal {
+		return ctrl.Result{RequeueAfter: w.resync}, nil
+	}
+	return ctrl.Result{RequeueAfter: policy.requeueAfter}, nil
+}
+
+// setReady sets the Ready condition, persists the status and returns the
+// requeue interval for it. A False Ready is also emitted as a Warning Event.
+func (w statusWriter) setReady(ctx context.Context, obj statusObject, status metav1.ConditionStatus, reason, message string) (ctrl.Result, error) {
+	meta.SetStatusCondition(obj.StatusConditions(), metav1.Condition{
+		Type:               accessv1alpha1.ConditionReady,
+		Status:             status,
+		ObservedGeneration: obj.GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	})
+	if status == metav1.ConditionTrue {
+		meta.RemoveStatusCondition(obj.StatusConditions(), accessv1alpha1.ConditionStalled)
+	}
+	if status == metav1.ConditionFalse {
+		w.recorder.Eventf(obj, nil, corev1.EventTypeWarning, reason, "Reconcile", "%s", message)
+	}
+	if err := w.client.Status().Update(ctx, obj); err != nil {
+		return ctrl.Result{}, err
+	}
+	if status != metav1.ConditionTrue {
+		return ctrl.Result{RequeueAfter: longRequeue}, nil
+	}
+	return ctrl.Result{RequeueAfter: w.resync}, nil
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	cfclient "github.com/twiechert/cf-zitadel-access-operator/internal/cloudflare"
+)
+
+// tunnelName returns the Cloudflare Tunnel app is routed through in Tunnel
+// mode, or "" if none is configured.
+func (r *SecuredApplicationReconciler) tunnelName(app *accessv1alpha1.SecuredApplication) string {
+	if app.Spec.Routing != nil && app.Spec.Routing.TunnelName != "" {
+		return app.Spec.Routing.TunnelName
+	}
+	return r.Config.TunnelName
+}
+
+// findTunnel resolves the tunnel app is routed through. The tunnel name must
+// be configured.
+func (r *SecuredApplicationReconciler) findTunnel(ctx context.Context, app *accessv1alpha1.SecuredApplication) (*cfclient.Tunnel, error) {
+	name := r.tunnelName(app)
+	tunnel, err := r.Cloudflare.FindTunnelByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("look up tunnel %q: %w", name, err)
+	}
+	if tunnel == nil {
+		return nil, fmt.Errorf("Cloudflare Tunnel %q not found", name)
+	}
+	return tunnel, nil
+}
+
+// reconcileTunnelRoutes adds the rules for each host to the tunnel's
+// configuration and points a proxied CNAME record at the tunnel. Routes in
+// status for another hostname or tunnel are removed afterwards.
+func (r *SecuredApplicationReconciler) reconcileTunnelRoutes(ctx context.Context, app *accessv1alpha1.SecuredApplication, tunnel *cfclient.Tunnel) error {
+	logger := log.FromContext(ctx)
+
+	rules := builder.TunnelIngressRules(app)
+	var current []accessv1alpha1.TunnelRouteStatus
+	for _, host := range builder.Hosts(app) {
+		// The DNS record goes first, so that a host whose record belongs to
+		// someone else is not routed through the tunnel at all.
+		zoneID, err := r.Cloudflare.FindZoneID(ctx, host)
+		if err != nil {
+			return err
+		}
+		recordID, changed, err := r.Cloudflare.UpsertCNAME(ctx, zoneID, host, tunnel.CNAMETarget())
+		if err != nil {
+			return fmt.Errorf("point %s at tunnel %s: %w", host, tunnel.Name, err)
+		}
+		if changed {
+			logger.Info("updated DNS record", "hostname", host, "recordId", recordID)
+			r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "UpdatedDNSRecord", "Update", "Pointed DNS record %s at tunnel %s", host, tunnel.Name)
+		}
+
+		hostRules := slices.DeleteFunc(slices.Clone(rules), func(rule cfclient.TunnelIngressRule) bool { return rule.Hostname != host })
+		changed, err = r.Cloudflare.UpsertTunnelRoutes(ctx, tunnel.ID, host, hostRules)
+		if err != nil {
+			return fmt.Errorf("route %s through tunnel %s: %w", host, tunnel.Name, err)
+		}
+		if changed {
+			logger.Info("updated tunnel routes", "hostname", host, "tunnelId", tunnel.ID, "rules", len(hostRules))
+			r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "UpdatedTunnelRoute", "Update", "Routed %s through tunnel %s (%d rules)", host, tunnel.Name, len(hostRules))
+		}
+		current = append(current, accessv1alpha1.TunnelRouteStatus{Hostname: host, TunnelID: tunnel.ID, ZoneID: zoneID, DNSRecordID: recordID})
+	}
+
+	for _, route := range app.Status.TunnelRoutes {
+		if slices.Contains(current, route) {
+			continue
+		}
+		// The CNAME of a kept hostname was updated in place above, and the
+		// rule in the current tunnel was just written.
+		if i := slices.IndexFunc(current, func(c accessv1alpha1.TunnelRouteStatus) bool { return c.Hostname == route.Hostname }); i >= 0 {
+			route.ZoneID, route.DNSRecordID = "", ""
+			if route.TunnelID == current[i].TunnelID {
+				continue
+			}
+		}
+		if err := r.deleteTunnelRoute(ctx, app, route); err != nil {
+			return err
+		}
+	}
+	app.Status.TunnelRoutes = current
+	return nil
+}
+
+// deleteTunnelRoutes removes every route in status, e.g. after switching away
+// from Tunnel mode or when app is deleted.
+func (r *SecuredApplicationReconciler) deleteTunnelRoutes(ctx context.Context, app *accessv1alpha1.SecuredApplication) error {
+	for len(app.Status.TunnelRoutes) > 0 {
+		if err := r.deleteTunnelRoute(ctx, app, app.Status.TunnelRoutes[0]); err != nil {
+			return err
+		}
+		app.Status.TunnelRoutes = app.Status.TunnelRoutes[1:]
+	}
+	app.Status.TunnelRoutes = nil
+	return nil
+}
+
+func (r *SecuredApplicationReconciler) deleteTunnelRoute(ctx context.Context, app *accessv1alpha1.SecuredApplication, route accessv1alpha1.TunnelRouteStatus) error {
+	log.FromContext(ctx).Info("removing tunnel route", "hostname", route.Hostname, "tunnelId", route.TunnelID)
+	if err := r.Cloudflare.DeleteTunnelRoute(ctx, route.TunnelID, route.Hostname); err != nil {
+		return fmt.Errorf("remove tunnel route for %s: %w", route.Hostname, err)
+	}
+	if route.DNSRecordID != "" {
+		if err := r.Cloudflare.DeleteDNSRecord(ctx, route.ZoneID, route.DNSRecordID); err != nil {
+			return fmt.Errorf("delete DNS record for %s: %w", route.Hostname, err)
+		}
+	}
+	r.Recorder.Eventf(app, nil, corev1.EventTypeNormal, "DeletedTunnelRoute", "Delete", "Removed tunnel route and DNS record for %s", route.Hostname)
+	return nil
+}
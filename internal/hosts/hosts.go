@@ -0,0 +1,69 @@
+// Package hosts decides which SecuredApplication may use a hostname. It is
+// shared by the controller, which waits while another object claims a host
+// first, and the admission webhook, which rejects such objects up front.
+package hosts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+)
+
+// IndexField indexes SecuredApplications by every hostname they claim. It
+// is registered by the controller and also used by the admission webhook.
+const IndexField = "spec.hosts"
+
+// Claimed returns the hostnames app claims: spec.host, spec.hosts and, if
+// set, the native OIDC host. Hostnames are case-insensitive, so they are
+// lowercased.
+func Claimed(app *accessv1alpha1.SecuredApplication) []string {
+	var hosts []string
+	for _, host := range builder.Hosts(app) {
+		hosts = append(hosts, strings.ToLower(host))
+	}
+	if app.Spec.NativeOIDC != nil && app.Spec.NativeOIDC.Ingress != nil && app.Spec.NativeOIDC.Ingress.Host != "" {
+		hosts = append(hosts, strings.ToLower(app.Spec.NativeOIDC.Ingress.Host))
+	}
+	return hosts
+}
+
+// Index is the client.IndexerFunc for IndexField.
+func Index(obj client.Object) []string {
+	return Claimed(obj.(*accessv1alpha1.SecuredApplication))
+}
+
+// ClaimsBefore reports whether a's claim on a shared host takes precedence
+// over b's. The older object wins; ties are broken by namespace/name so the
+// order is stable.
+func ClaimsBefore(a, b *accessv1alpha1.SecuredApplication) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return types.NamespacedName{Namespace: a.Namespace, Name: a.Name}.String() <
+		types.NamespacedName{Namespace: b.Namespace, Name: b.Name}.String()
+}
+
+// Conflicts returns the SecuredApplications other than app that claim one
+// of app's hosts, keyed by host. c must have the IndexField index.
+func Conflicts(ctx context.Context, c client.Reader, app *accessv1alpha1.SecuredApplication) (map[string][]accessv1alpha1.SecuredApplication, error) {
+	conflicts := make(map[string][]accessv1alpha1.SecuredApplication)
+	for _, host := range Claimed(app) {
+		var list accessv1alpha1.SecuredApplicationList
+		if err := c.List(ctx, &list, client.MatchingFields{IndexField: host}); err != nil {
+			return nil, fmt.Errorf("list SecuredApplications for host %s: %w", host, err)
+		}
+		for _, other := range list.Items {
+			if other.Namespace == app.Namespace && other.Name == app.Name {
+				continue
+			}
+			conflicts[host] = append(conflicts[host], other)
+		}
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+)
+
+var (
+	managedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "managed_resources"),
+		"External resources currently managed by the operator, by kind.",
+		[]string{"kind"}, nil,
+	)
+	notReadyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "securedapplications_not_ready"),
+		"SecuredApplications whose Ready condition is not True, by reason.",
+		[]string{"reason"}, nil,
+	)
+)
+
+// securedApplicationCollector computes gauges from the SecuredApplications
+// in the cache at scrape time, so they never go stale after a delete.
+type securedApplicationCollector struct {
+	client client.Reader
+}
+
+// NewSecuredApplicationCollector returns a collector reporting managed
+// resource counts and not-Ready SecuredApplications. c should be a cached
+// reader, e.g. the manager's client.
+func NewSecuredApplicationCollector(c client.Reader) prometheus.Collector {
+	return &securedApplicationCollector{client: c}
+}
+
+func (c *securedApplicationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- managedDesc
+	ch <- notReadyDesc
+}
+
+func (c *securedApplicationCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var list accessv1alpha1.SecuredApplicationList
+	if err := c.client.List(ctx, &list); err != nil {
+		logf.Log.WithName("metrics").Error(err, "failed to list SecuredApplications")
+		return
+	}
+
+	managed := map[string]int{KindZitadelApp: 0, KindAccessApplication: 0, KindBypassApp: 0, KindPathApp: 0}
+	notReady := make(map[string]int)
+	for _, app := range list.Items {
+		if app.Status.ZitadelAppID != "" {
+			managed[KindZitadelApp]++
+		}
+		if app.Status.AccessApplicationID != "" {
+			managed[KindAccessApplication]++
+		}
+		managed[KindBypassApp] += len(app.Status.BypassApplicationIDs)
+		managed[KindPathApp] += len(app.Status.PathApplicationIDs)
+
+		ready := meta.FindStatusCondition(app.Status.Conditions, accessv1alpha1.ConditionReady)
+		switch {
+		case ready == nil:
+			notReady["Pending"]++
+		case ready.Status != metav1.ConditionTrue:
+			notReady[ready.Reason]++
+		}
+	}
+
+	for kind, n := range managed {
+		ch <- prometheus.MustNewConstMetric(managedDesc, prometheus.GaugeValue, float64(n), kind)
+	}
+	for reason, n := range notReady {
+		ch <- prometheus.MustNewConstMetric(notReadyDesc, prometheus.GaugeValue, float64(n), reason)
+	}
+}
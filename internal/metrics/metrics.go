@@ -0,0 +1,82 @@
+// Package metrics defines the operator's Prometheus metrics. They are
+// registered with controller-runtime's registry and served on the manager's
+// metrics endpoint.
+package metrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "cf_zitadel_access"
+
+var (
+	apiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_requests_total",
+		Help:      "Requests sent to the Cloudflare and Zitadel APIs, by provider, method, endpoint and status code.",
+	}, []string{"provider", "method", "endpoint", "code"})
+
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of requests to the Cloudflare and Zitadel APIs.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"provider", "method", "endpoint"})
+
+	adoptions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "adoptions_total",
+		Help:      "Existing external resources adopted instead of created, by kind.",
+	}, []string{"kind"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(apiRequests, apiRequestDuration, adoptions)
+}
+
+// Resource kinds used as the "kind" label.
+const (
+	KindZitadelApp        = "zitadel_app"
+	KindAccessApplication = "access_application"
+	KindBypassApp         = "bypass_app"
+	KindPathApp           = "path_app"
+)
+
+// ObserveAPIRequest records a request to an external API. statusCode is 0
+// when no response was received.
+func ObserveAPIRequest(provider, method, path string, statusCode int, duration time.Duration) {
+	endpoint := Endpoint(path)
+	code := "error"
+	if statusCode != 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	apiRequests.WithLabelValues(provider, method, endpoint, code).Inc()
+	apiRequestDuration.WithLabelValues(provider, method, endpoint).Observe(duration.Seconds())
+}
+
+// RecordAdoption counts an adopted external resource.
+func RecordAdoption(kind string) {
+	adoptions.WithLabelValues(kind).Inc()
+}
+
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// Endpoint turns a request path into a low-cardinality label by dropping the
+// query and replacing ID segments (anything containing a digit, except API
+// versions like v1) with {id}, e.g. /accounts/{id}/access/apps/{id}.
+func Endpoint(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.ContainsAny(s, "0123456789") && !versionSegment.MatchString(s) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,112 @@
+// Package retry sends API requests again after rate limits and server
+// errors. It is shared by the Cloudflare and Zitadel clients.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxAttempts is the number of times a request is sent before giving up
+	// on rate limits and server errors.
+	maxAttempts = 4
+
+	baseBackoff = 500 * time.Millisecond
+	maxBackoff  = 8 * time.Second
+
+	// maxRetryAfter caps how long Do waits inline for a Retry-After.
+	// Longer delays are returned to the caller to requeue instead.
+	maxRetryAfter = 30 * time.Second
+)
+
+// Error is implemented by the API errors of the clients, exposing what Do
+// needs to decide whether to send a request again.
+type Error interface {
+	error
+	HTTPStatus() int
+	RetryDelay() time.Duration
+}
+
+// Idempotent reports whether sending a request with method twice has the
+// same effect as sending it once.
+func Idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Do calls send until it succeeds, fails with an error that may not be
+// retried, or maxAttempts is reached. A 429 is always retried, since the
+// server turned the request away without acting on it. A 5xx is only
+// retried if idempotent is set: the failed request may still have been
+// applied, and sending a create again would duplicate the resource.
+//
+// Waits use jittered exponential backoff, or the server's Retry-After when
+// it is short enough to wait for inline.
+func Do[T any](ctx context.Context, idempotent bool, send func() (T, error)) (T, error) {
+	var zero T
+	for attempt := 1; ; attempt++ {
+		result, err := send()
+		if err == nil {
+			return result, nil
+		}
+
+		var apiErr Error
+		if !errors.As(err, &apiErr) || !retryable(apiErr.HTTPStatus(), idempotent) || attempt >= maxAttempts {
+			return zero, err
+		}
+		if apiErr.RetryDelay() > maxRetryAfter {
+			return zero, err
+		}
+
+		delay := backoff(attempt)
+		if apiErr.RetryDelay() > delay {
+			delay = apiErr.RetryDelay()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, err
+		case <-timer.C:
+		}
+	}
+}
+
+func retryable(status int, idempotent bool) bool {
+	return status == http.StatusTooManyRequests || (idempotent && status >= 500)
+}
+
+// backoff returns a random delay in [d/2, d) where d doubles per attempt.
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << (attempt - 1)
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// ParseRetryAfter reads a Retry-After header given either in seconds or as
+// an HTTP date.
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
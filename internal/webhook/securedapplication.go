@@ -0,0 +1,368 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/hosts"
+)
+
+var (
+	pathTypes = []string{"Exact", "Prefix", "ImplementationSpecific"}
+
+	oidcResponseTypes = []string{
+		"OIDC_RESPONSE_TYPE_CODE",
+		"OIDC_RESPONSE_TYPE_ID_TOKEN",
+		"OIDC_RESPONSE_TYPE_ID_TOKEN_TOKEN",
+	}
+	oidcGrantTypes = []string{
+		"OIDC_GRANT_TYPE_AUTHORIZATION_CODE",
+		"OIDC_GRANT_TYPE_IMPLICIT",
+		"OIDC_GRANT_TYPE_REFRESH_TOKEN",
+		"OIDC_GRANT_TYPE_DEVICE_CODE",
+		"OIDC_GRANT_TYPE_TOKEN_EXCHANGE",
+	}
+	oidcAppTypes = []string{
+		"OIDC_APP_TYPE_WEB",
+		"OIDC_APP_TYPE_USER_AGENT",
+		"OIDC_APP_TYPE_NATIVE",
+	}
+	oidcAuthMethodTypes = []string{
+		"OIDC_AUTH_METHOD_TYPE_BASIC",
+		"OIDC_AUTH_METHOD_TYPE_POST",
+		"OIDC_AUTH_METHOD_TYPE_NONE",
+		"OIDC_AUTH_METHOD_TYPE_PRIVATE_KEY_JWT",
+	}
+	oidcTokenTypes = []string{
+		"OIDC_TOKEN_TYPE_BEARER",
+		"OIDC_TOKEN_TYPE_JWT",
+	}
+)
+
+// +kubebuilder:webhook:path=/validate-access-twiechert-de-v1alpha1-securedapplication,mutating=false,failurePolicy=fail,sideEffects=None,groups=access.twiechert.de,resources=securedapplications,verbs=create;update,versions=v1alpha1,name=vsecuredapplication.access.twiechert.de,admissionReviewVersions=v1
+
+// SecuredApplicationValidator rejects SecuredApplications whose spec the
+// controller could never reconcile, before any external side effects happen.
+type SecuredApplicationValidator struct {
+	// Client is used to reject hosts already claimed by another
+	// SecuredApplication. It must serve the hosts.IndexField index.
+	Client client.Reader
+}
+
+func (v *SecuredApplicationValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &accessv1alpha1.SecuredApplication{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *SecuredApplicationValidator) ValidateCreate(ctx context.Context, app *accessv1alpha1.SecuredApplication) (admission.Warnings, error) {
+	errs := ValidateSecuredApplication(app)
+	hostErrs, err := v.validateHosts(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	return nil, toError(app, append(errs, hostErrs...))
+}
+
+func (v *SecuredApplicationValidator) ValidateUpdate(ctx context.Context, _, app *accessv1alpha1.SecuredApplication) (admission.Warnings, error) {
+	// Let objects that are being deleted through so the finalizer can be removed.
+	if !app.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	errs := ValidateSecuredApplication(app)
+	hostErrs, err := v.validateHosts(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	return nil, toError(app, append(errs, hostErrs...))
+}
+
+func (v *SecuredApplicationValidator) ValidateDelete(context.Context, *accessv1alpha1.SecuredApplication) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func toError(app *accessv1alpha1.SecuredApplication, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(accessv1alpha1.GroupVersion.WithKind("SecuredApplication").GroupKind(), app.Name, errs)
+}
+
+// validateHosts rejects hosts that an earlier SecuredApplication already
+// claims. On create every existing claimant is earlier; on update an object
+// that already lost a conflict is not blocked from being edited to fix it.
+func (v *SecuredApplicationValidator) validateHosts(ctx context.Context, app *accessv1alpha1.SecuredApplication) (field.ErrorList, error) {
+	if v.Client == nil {
+		return nil, nil
+	}
+	conflicts, err := hosts.Conflicts(ctx, v.Client, app)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs field.ErrorList
+	hostPaths := claimedHostPaths(app)
+	for i, host := range hosts.Claimed(app) {
+		for _, other := range conflicts[host] {
+			if app.CreationTimestamp.IsZero() || hosts.ClaimsBefore(&other, app) {
+				errs = append(errs, field.Forbidden(hostPaths[i],
+					fmt.Sprintf("host %q is already claimed by SecuredApplication %s/%s", host, other.Namespace, other.Name)))
+				break
+			}
+		}
+	}
+	return errs, nil
+}
+
+// claimedHostPaths returns the field path of each host returned by
+// hosts.Claimed, in the same order.
+func claimedHostPaths(app *accessv1alpha1.SecuredApplication) []*field.Path {
+	spec := field.NewPath("spec")
+	var paths []*field.Path
+	for _, host := range builder.Hosts(app) {
+		if host == app.Spec.Host {
+			paths = append(paths, spec.Child("host"))
+		} else {
+			paths = append(paths, spec.Child("hosts").Index(slices.Index(app.Spec.Hosts, host)))
+		}
+	}
+	return append(paths, spec.Child("nativeOIDC", "ingress", "host"))
+}
+
+// ValidateSecuredApplication checks the rules that the CRD schema cannot express.
+func ValidateSecuredApplication(app *accessv1alpha1.SecuredApplication) field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+
+	errs = append(errs, validateAccess(&app.Spec.Access, spec.Child("access"))...)
+
+	if app.Spec.Ingress != nil {
+		errs = append(errs, validatePath(app.Spec.Ingress.Path, spec.Child("ingress", "path"))...)
+		errs = append(errs, validateEnum(app.Spec.Ingress.PathType, pathTypes, spec.Child("ingress", "pathType"))...)
+		if len(app.Spec.Routes) > 0 && app.Spec.Ingress.Path != "" {
+			errs = append(errs, field.Forbidden(spec.Child("ingress", "path"), "must not be set together with spec.routes"))
+		}
+	}
+
+	errs = append(errs, validateRoutes(app, spec.Child("routes"))...)
+
+	if app.Spec.NativeOIDC != nil {
+		errs = append(errs, validateNativeOIDC(app, spec.Child("nativeOIDC"))...)
+	}
+
+	if app.Spec.Routing != nil && app.Spec.Routing.Mode == "HTTPRoute" && app.Spec.Routing.Gateway == nil {
+		errs = append(errs, field.Required(spec.Child("routing", "gateway"), "required when mode is HTTPRoute"))
+	}
+
+	return errs
+}
+
+func validateRoutes(app *accessv1alpha1.SecuredApplication, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, route := range app.Spec.Routes {
+		errs = append(errs, validatePath(route.Path, path.Index(i).Child("path"))...)
+		errs = append(errs, validateEnum(route.PathType, pathTypes, path.Index(i).Child("pathType"))...)
+	}
+
+	// The Ingress annotation sets the protocol for all of its paths.
+	mode := ""
+	if app.Spec.Routing != nil {
+		mode = app.Spec.Routing.Mode
+	}
+	if len(app.Spec.Routes) > 1 && mode != "HTTPRoute" && mode != "Tunnel" && builder.BackendProtocol(app) == "" {
+		for i, route := range app.Spec.Routes {
+			if route.Backend.Protocol != "" {
+				errs = append(errs, field.Invalid(path.Index(i).Child("backend", "protocol"), route.Backend.Protocol, "must be the same for every route in Ingress routing mode"))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+func validateAccess(access *accessv1alpha1.Access, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	hasAllowPolicy := len(access.Roles) > 0 || len(access.Claims) > 0 || len(access.Include) > 0
+	if !hasAllowPolicy && len(access.Policies) == 0 && len(access.PolicyRefs) == 0 && len(access.ServiceTokens) == 0 {
+		errs = append(errs, field.Required(path.Child("roles"), "at least one of roles, claims, include, policies, policyRefs or serviceTokens must be specified"))
+	}
+	errs = append(errs, validateRules(access.Include, path.Child("include"))...)
+	errs = append(errs, validateRules(access.Require, path.Child("require"))...)
+	errs = append(errs, validateRules(access.Exclude, path.Child("exclude"))...)
+
+	// Cloudflare rejects two policies with the same precedence on one app.
+	// Policies without a precedence follow the generated one in list order.
+	precedences := make(map[int]bool, len(access.Policies)+1)
+	offset := 0
+	if hasAllowPolicy {
+		precedences[1] = true
+		offset = 1
+	}
+	for i, policy := range access.Policies {
+		idx := path.Child("policies").Index(i)
+		errs = append(errs, validateRules(policy.Include, idx.Child("include"))...)
+		errs = append(errs, validateRules(policy.Require, idx.Child("require"))...)
+		errs = append(errs, validateRules(policy.Exclude, idx.Child("exclude"))...)
+
+		precedence := policy.Precedence
+		if precedence == 0 {
+			precedence = offset + i + 1
+		}
+		if precedences[precedence] {
+			errs = append(errs, field.Invalid(idx.Child("precedence"), precedence, "is already used by another policy of this application"))
+		}
+		precedences[precedence] = true
+
+		if len(access.ServiceTokens) > 0 && policy.Name == builder.ServiceTokenPolicyName {
+			errs = append(errs, field.Invalid(idx.Child("name"), policy.Name, "is reserved for the service token policy"))
+		}
+	}
+
+	secretNames := make(map[string]bool, len(access.ServiceTokens))
+	for i, token := range access.ServiceTokens {
+		idx := path.Child("serviceTokens").Index(i)
+		if token.Duration != nil && token.Duration.Duration <= 0 {
+			errs = append(errs, field.Invalid(idx.Child("duration"), token.Duration.Duration.String(), "must be positive"))
+		}
+		if token.SecretName == "" {
+			continue
+		}
+		if secretNames[token.SecretName] {
+			errs = append(errs, field.Duplicate(idx.Child("secretName"), token.SecretName))
+		}
+		secretNames[token.SecretName] = true
+	}
+
+	seen := make(map[string]bool, len(access.BypassPaths))
+	for i, p := range access.BypassPaths {
+		idx := path.Child("bypassPaths").Index(i)
+		if !strings.HasPrefix(p, "/") {
+			errs = append(errs, field.Invalid(idx, p, "must start with a slash"))
+		}
+		if seen[p] {
+			errs = append(errs, field.Duplicate(idx, p))
+		}
+		seen[p] = true
+	}
+
+	for i, rule := range access.PathRules {
+		idx := path.Child("pathRules").Index(i)
+		switch {
+		case !strings.HasPrefix(rule.Path, "/"):
+			errs = append(errs, field.Invalid(idx.Child("path"), rule.Path, "must start with a slash"))
+		case rule.Path == "/":
+			errs = append(errs, field.Invalid(idx.Child("path"), rule.Path, "must not be the root path, which spec.access covers"))
+		case seen[rule.Path]:
+			errs = append(errs, field.Invalid(idx.Child("path"), rule.Path, "is already a bypass path"))
+		}
+		if len(rule.Roles) == 0 && len(rule.Claims) == 0 && len(rule.Include) == 0 {
+			errs = append(errs, field.Required(idx.Child("roles"), "at least one of roles, claims or include must be specified"))
+		}
+		errs = append(errs, validateRules(rule.Include, idx.Child("include"))...)
+		errs = append(errs, validateRules(rule.Require, idx.Child("require"))...)
+		errs = append(errs, validateRules(rule.Exclude, idx.Child("exclude"))...)
+	}
+
+	return errs
+}
+
+// validateRules checks that every rule sets exactly one selector and that
+// IP rules hold an address or CIDR range.
+func validateRules(rules []accessv1alpha1.AccessRule, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, rule := range rules {
+		idx := path.Index(i)
+		selectors := 0
+		for _, set := range []bool{
+			rule.Role != "", rule.Claim != nil, rule.Email != "", rule.EmailDomain != "",
+			rule.IP != "", rule.Country != "", rule.GroupID != "", rule.GroupRef != "", rule.ServiceTokenID != "",
+			rule.AnyValidServiceToken, rule.Certificate, rule.CommonName != "", rule.DevicePosture != "",
+		} {
+			if set {
+				selectors++
+			}
+		}
+		if selectors != 1 {
+			errs = append(errs, field.Invalid(idx, selectors, "exactly one rule selector must be set"))
+		}
+		if rule.IP != "" && net.ParseIP(rule.IP) == nil {
+			if _, _, err := net.ParseCIDR(rule.IP); err != nil {
+				errs = append(errs, field.Invalid(idx.Child("ip"), rule.IP, "must be an IP address or CIDR range"))
+			}
+		}
+	}
+	return errs
+}
+
+func validateNativeOIDC(app *accessv1alpha1.SecuredApplication, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	oidc := app.Spec.NativeOIDC
+
+	errs = append(errs, validatePath(oidc.RedirectPath, path.Child("redirectPath"))...)
+	errs = append(errs, validatePath(oidc.PostLogoutRedirectPath, path.Child("postLogoutRedirectPath"))...)
+
+	for i, v := range oidc.ResponseTypes {
+		errs = append(errs, validateEnum(v, oidcResponseTypes, path.Child("responseTypes").Index(i))...)
+	}
+	for i, v := range oidc.GrantTypes {
+		errs = append(errs, validateEnum(v, oidcGrantTypes, path.Child("grantTypes").Index(i))...)
+	}
+	errs = append(errs, validateEnum(oidc.AppType, oidcAppTypes, path.Child("appType"))...)
+	errs = append(errs, validateEnum(oidc.AuthMethodType, oidcAuthMethodTypes, path.Child("authMethodType"))...)
+	errs = append(errs, validateEnum(oidc.AccessTokenType, oidcTokenTypes, path.Child("accessTokenType"))...)
+
+	if oidc.SecretRotationInterval != nil && oidc.SecretRotationInterval.Duration < 0 {
+		errs = append(errs, field.Invalid(path.Child("secretRotationInterval"), oidc.SecretRotationInterval.Duration.String(), "must not be negative"))
+	}
+
+	if ingress := oidc.Ingress; ingress != nil {
+		ingressPath := path.Child("ingress")
+		if slices.Contains(builder.Hosts(app), ingress.Host) {
+			errs = append(errs, field.Invalid(ingressPath.Child("host"), ingress.Host, "must differ from spec.host and spec.hosts"))
+		}
+		httpRoute := app.Spec.Routing != nil && app.Spec.Routing.Mode == "HTTPRoute"
+		if ingress.ClassName == "" && !httpRoute {
+			errs = append(errs, field.Required(ingressPath.Child("className"), "required unless routing mode is HTTPRoute"))
+		}
+		errs = append(errs, validatePath(ingress.Path, ingressPath.Child("path"))...)
+		errs = append(errs, validateEnum(ingress.PathType, pathTypes, ingressPath.Child("pathType"))...)
+		if len(app.Spec.Routes) > 0 && ingress.Path != "" {
+			errs = append(errs, field.Forbidden(ingressPath.Child("path"), "must not be set together with spec.routes"))
+		}
+	}
+
+	return errs
+}
+
+// validatePath checks that an optional URL path starts with a slash.
+func validatePath(p string, path *field.Path) field.ErrorList {
+	if p != "" && !strings.HasPrefix(p, "/") {
+		return field.ErrorList{field.Invalid(path, p, "must start with a slash")}
+	}
+	return nil
+}
+
+// validateEnum checks that an optional value is one of the allowed values.
+func validateEnum(v string, allowed []string, path *field.Path) field.ErrorList {
+	if v == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if v == a {
+			return nil
+		}
+	}
+	return field.ErrorList{field.NotSupported(path, v, allowed)}
+}
@@ -0,0 +1,687 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	accessv1alpha1 "github.com/twiechert/cf-zitadel-access-operator/api/v1alpha1"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/builder"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/hosts"
+)
+
+// validApp returns a SecuredApplication that passes validation.
+func validApp() *accessv1alpha1.SecuredApplication {
+	app := &accessv1alpha1.SecuredApplication{}
+	app.Name = "wiki"
+	app.Namespace = "default"
+	app.Spec.Host = "wiki.example.com"
+	app.Spec.Access = accessv1alpha1.Access{Project: "infrastructure", Roles: []string{"admin"}}
+	app.Spec.Backend = &accessv1alpha1.Backend{ServiceName: "wiki", ServicePort: 8080}
+	return app
+}
+
+func duration(d time.Duration) *metav1.Duration {
+	return &metav1.Duration{Duration: d}
+}
+
+func TestValidateSecuredApplication(t *testing.T) {
+	twoRoutes := func(app *accessv1alpha1.SecuredApplication) {
+		app.Spec.Backend = nil
+		app.Spec.Routes = []accessv1alpha1.Route{
+			{Path: "/api", Backend: accessv1alpha1.Backend{ServiceName: "api", ServicePort: 443, Protocol: "https"}},
+			{Path: "/", Backend: accessv1alpha1.Backend{ServiceName: "web", ServicePort: 80}},
+		}
+	}
+	nativeOIDC := func(app *accessv1alpha1.SecuredApplication) {
+		app.Spec.NativeOIDC = &accessv1alpha1.NativeOIDCConfig{
+			Ingress: &accessv1alpha1.OIDCIngressConfig{Host: "wiki-internal.example.com", ClassName: "nginx"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(app *accessv1alpha1.SecuredApplication)
+		// fields lists the field paths of the expected errors, in order.
+		fields []string
+	}{
+		{name: "roles only", mutate: func(*accessv1alpha1.SecuredApplication) {}},
+
+		// access
+		{
+			name: "no access rule at all",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Roles = nil
+			},
+			fields: []string{"spec.access.roles"},
+		},
+		{
+			name: "claims only",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Roles = nil
+				app.Spec.Access.Claims = []accessv1alpha1.ClaimCheck{{Name: "custom:department", Value: "it"}}
+			},
+		},
+		{
+			name: "include only",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Roles = nil
+				app.Spec.Access.Include = []accessv1alpha1.AccessRule{{EmailDomain: "example.com"}}
+			},
+		},
+		{
+			name: "policies only",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Roles = nil
+				app.Spec.Access.Policies = []accessv1alpha1.AccessPolicyConfig{
+					{Name: "staff", Decision: "allow", Precedence: 1, Include: []accessv1alpha1.AccessRule{{Email: "alice@example.com"}}},
+				}
+			},
+		},
+		{
+			name: "policyRefs only",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Roles = nil
+				app.Spec.Access.PolicyRefs = []string{"office-network"}
+			},
+		},
+		{
+			name: "serviceTokens only",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Roles = nil
+				app.Spec.Access.ServiceTokens = []accessv1alpha1.ServiceTokenConfig{{Name: "ci"}}
+			},
+		},
+
+		// rules
+		{
+			name: "rule with two selectors",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Include = []accessv1alpha1.AccessRule{{Email: "alice@example.com", Country: "DE"}}
+			},
+			fields: []string{"spec.access.include[0]"},
+		},
+		{
+			name: "rule without selector",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Require = []accessv1alpha1.AccessRule{{}}
+			},
+			fields: []string{"spec.access.require[0]"},
+		},
+		{
+			name: "invalid IP rule",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Exclude = []accessv1alpha1.AccessRule{{IP: "10.0.0.0/33"}}
+			},
+			fields: []string{"spec.access.exclude[0].ip"},
+		},
+		{
+			name: "IP address and CIDR rules",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Exclude = []accessv1alpha1.AccessRule{{IP: "203.0.113.7"}, {IP: "2001:db8::/32"}}
+			},
+		},
+		{
+			name: "groupRef rule",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Include = []accessv1alpha1.AccessRule{{GroupRef: "on-call"}}
+			},
+		},
+		{
+			name: "groupRef and groupId in one rule",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Include = []accessv1alpha1.AccessRule{{GroupRef: "on-call", GroupID: "3f2a"}}
+			},
+			fields: []string{"spec.access.include[0]"},
+		},
+		{
+			name: "invalid rule in a policy",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Policies = []accessv1alpha1.AccessPolicyConfig{
+					{Name: "staff", Decision: "allow", Include: []accessv1alpha1.AccessRule{{}}, Exclude: []accessv1alpha1.AccessRule{{IP: "nope"}}},
+				}
+			},
+			fields: []string{"spec.access.policies[0].include[0]", "spec.access.policies[0].exclude[0].ip"},
+		},
+		{
+			name: "invalid rule in a path rule",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.PathRules = []accessv1alpha1.PathRule{
+					{Path: "/admin", Roles: []string{"admin"}, Require: []accessv1alpha1.AccessRule{{Role: "admin", Email: "alice@example.com"}}},
+				}
+			},
+			fields: []string{"spec.access.pathRules[0].require[0]"},
+		},
+
+		// policies
+		{
+			name: "policy precedence taken by the allow policy",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Policies = []accessv1alpha1.AccessPolicyConfig{
+					{Name: "staff", Decision: "allow", Precedence: 1, Include: []accessv1alpha1.AccessRule{{Email: "alice@example.com"}}},
+				}
+			},
+			fields: []string{"spec.access.policies[0].precedence"},
+		},
+		{
+			name: "implicit policy precedence clashes with an explicit one",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Policies = []accessv1alpha1.AccessPolicyConfig{
+					{Name: "staff", Decision: "allow", Precedence: 3, Include: []accessv1alpha1.AccessRule{{Email: "alice@example.com"}}},
+					{Name: "office", Decision: "allow", Include: []accessv1alpha1.AccessRule{{IP: "203.0.113.0/24"}}},
+				}
+			},
+			fields: []string{"spec.access.policies[1].precedence"},
+		},
+		{
+			name: "policies after the allow policy",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Policies = []accessv1alpha1.AccessPolicyConfig{
+					{Name: "staff", Decision: "allow", Include: []accessv1alpha1.AccessRule{{Email: "alice@example.com"}}},
+					{Name: "blocked", Decision: "deny", Precedence: 5, Include: []accessv1alpha1.AccessRule{{Country: "KP"}}},
+				}
+			},
+		},
+		{
+			name: "reserved policy name with service tokens",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.ServiceTokens = []accessv1alpha1.ServiceTokenConfig{{Name: "ci"}}
+				app.Spec.Access.Policies = []accessv1alpha1.AccessPolicyConfig{
+					{Name: builder.ServiceTokenPolicyName, Decision: "allow", Precedence: 4, Include: []accessv1alpha1.AccessRule{{Email: "alice@example.com"}}},
+				}
+			},
+			fields: []string{"spec.access.policies[0].name"},
+		},
+		{
+			name: "reserved policy name without service tokens",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.Policies = []accessv1alpha1.AccessPolicyConfig{
+					{Name: builder.ServiceTokenPolicyName, Decision: "allow", Precedence: 4, Include: []accessv1alpha1.AccessRule{{Email: "alice@example.com"}}},
+				}
+			},
+		},
+
+		// service tokens
+		{
+			name: "non-positive service token duration",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.ServiceTokens = []accessv1alpha1.ServiceTokenConfig{{Name: "ci", Duration: duration(0)}}
+			},
+			fields: []string{"spec.access.serviceTokens[0].duration"},
+		},
+		{
+			name: "duplicate service token secret",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.ServiceTokens = []accessv1alpha1.ServiceTokenConfig{
+					{Name: "ci", SecretName: "tokens"},
+					{Name: "cron", SecretName: "tokens"},
+				}
+			},
+			fields: []string{"spec.access.serviceTokens[1].secretName"},
+		},
+		{
+			name: "service tokens with default secrets and a duration",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.ServiceTokens = []accessv1alpha1.ServiceTokenConfig{
+					{Name: "ci", Duration: duration(24 * time.Hour)},
+					{Name: "cron"},
+				}
+			},
+		},
+
+		// bypass paths
+		{
+			name: "bypass path without slash",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.BypassPaths = []string{"healthz"}
+			},
+			fields: []string{"spec.access.bypassPaths[0]"},
+		},
+		{
+			name: "duplicate bypass path",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.BypassPaths = []string{"/healthz", "/metrics", "/healthz"}
+			},
+			fields: []string{"spec.access.bypassPaths[2]"},
+		},
+
+		// path rules
+		{
+			name: "path rule without slash",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.PathRules = []accessv1alpha1.PathRule{{Path: "admin", Roles: []string{"admin"}}}
+			},
+			fields: []string{"spec.access.pathRules[0].path"},
+		},
+		{
+			name: "path rule on the root path",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.PathRules = []accessv1alpha1.PathRule{{Path: "/", Roles: []string{"admin"}}}
+			},
+			fields: []string{"spec.access.pathRules[0].path"},
+		},
+		{
+			name: "path rule on a bypass path",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.BypassPaths = []string{"/healthz"}
+				app.Spec.Access.PathRules = []accessv1alpha1.PathRule{{Path: "/healthz", Roles: []string{"admin"}}}
+			},
+			fields: []string{"spec.access.pathRules[0].path"},
+		},
+		{
+			name: "path rule without access rule",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.PathRules = []accessv1alpha1.PathRule{{Path: "/admin", Require: []accessv1alpha1.AccessRule{{Country: "DE"}}}}
+			},
+			fields: []string{"spec.access.pathRules[0].roles"},
+		},
+		{
+			name: "path rules and bypass paths",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Access.BypassPaths = []string{"/healthz"}
+				app.Spec.Access.PathRules = []accessv1alpha1.PathRule{
+					{Path: "/admin", Roles: []string{"admin"}},
+					{Path: "/reports", Match: "all", Claims: []accessv1alpha1.ClaimCheck{{Name: "custom:department", Value: "finance"}}},
+				}
+			},
+		},
+
+		// ingress
+		{
+			name: "ingress path without slash and unknown pathType",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Ingress = &accessv1alpha1.IngressConfig{Path: "app", PathType: "Regex"}
+			},
+			fields: []string{"spec.ingress.path", "spec.ingress.pathType"},
+		},
+		{
+			name: "ingress class and path",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Ingress = &accessv1alpha1.IngressConfig{ClassName: "nginx", Path: "/app", PathType: "Exact"}
+			},
+		},
+		{
+			name: "ingress path together with routes",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Backend = nil
+				app.Spec.Routes = []accessv1alpha1.Route{{Path: "/", Backend: accessv1alpha1.Backend{ServiceName: "web", ServicePort: 80}}}
+				app.Spec.Ingress = &accessv1alpha1.IngressConfig{Path: "/app"}
+			},
+			fields: []string{"spec.ingress.path"},
+		},
+
+		// routes
+		{
+			name: "route path without slash and unknown pathType",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Backend = nil
+				app.Spec.Routes = []accessv1alpha1.Route{{Path: "api", PathType: "Regex", Backend: accessv1alpha1.Backend{ServiceName: "api", ServicePort: 80}}}
+			},
+			fields: []string{"spec.routes[0].path", "spec.routes[0].pathType"},
+		},
+		{
+			name:   "route protocols differ in Ingress mode",
+			mutate: twoRoutes,
+			fields: []string{"spec.routes[0].backend.protocol"},
+		},
+		{
+			name: "route protocols differ in HTTPRoute mode",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				twoRoutes(app)
+				app.Spec.Routing = &accessv1alpha1.RoutingConfig{Mode: "HTTPRoute", Gateway: &accessv1alpha1.GatewayRef{Name: "public"}}
+			},
+		},
+		{
+			name: "route protocols differ in Tunnel mode",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				twoRoutes(app)
+				app.Spec.Routing = &accessv1alpha1.RoutingConfig{Mode: "Tunnel", TunnelName: "home"}
+			},
+		},
+		{
+			name: "same route protocol in Ingress mode",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				twoRoutes(app)
+				app.Spec.Routes[1].Backend.Protocol = "https"
+			},
+		},
+
+		// routing
+		{
+			name: "HTTPRoute mode without gateway",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				app.Spec.Routing = &accessv1alpha1.RoutingConfig{Mode: "HTTPRoute"}
+			},
+			fields: []string{"spec.routing.gateway"},
+		},
+
+		// nativeOIDC
+		{name: "nativeOIDC with ingress", mutate: nativeOIDC},
+		{
+			name: "nativeOIDC paths without slash",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.RedirectPath = "callback"
+				app.Spec.NativeOIDC.PostLogoutRedirectPath = "logout"
+			},
+			fields: []string{"spec.nativeOIDC.redirectPath", "spec.nativeOIDC.postLogoutRedirectPath"},
+		},
+		{
+			name: "nativeOIDC unknown enum values",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.ResponseTypes = []string{"OIDC_RESPONSE_TYPE_CODE", "CODE"}
+				app.Spec.NativeOIDC.GrantTypes = []string{"PASSWORD"}
+				app.Spec.NativeOIDC.AppType = "OIDC_APP_TYPE_SPA"
+				app.Spec.NativeOIDC.AuthMethodType = "BASIC"
+				app.Spec.NativeOIDC.AccessTokenType = "OPAQUE"
+			},
+			fields: []string{
+				"spec.nativeOIDC.responseTypes[1]",
+				"spec.nativeOIDC.grantTypes[0]",
+				"spec.nativeOIDC.appType",
+				"spec.nativeOIDC.authMethodType",
+				"spec.nativeOIDC.accessTokenType",
+			},
+		},
+		{
+			name: "nativeOIDC known enum values",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.ResponseTypes = []string{"OIDC_RESPONSE_TYPE_CODE"}
+				app.Spec.NativeOIDC.GrantTypes = []string{"OIDC_GRANT_TYPE_AUTHORIZATION_CODE", "OIDC_GRANT_TYPE_REFRESH_TOKEN"}
+				app.Spec.NativeOIDC.AppType = "OIDC_APP_TYPE_USER_AGENT"
+				app.Spec.NativeOIDC.AuthMethodType = "OIDC_AUTH_METHOD_TYPE_NONE"
+				app.Spec.NativeOIDC.AccessTokenType = "OIDC_TOKEN_TYPE_JWT"
+				app.Spec.NativeOIDC.SecretRotationInterval = duration(0)
+			},
+		},
+		{
+			name: "nativeOIDC negative rotation interval",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.SecretRotationInterval = duration(-time.Hour)
+			},
+			fields: []string{"spec.nativeOIDC.secretRotationInterval"},
+		},
+		{
+			name: "nativeOIDC ingress host is spec.host",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.Ingress.Host = "wiki.example.com"
+			},
+			fields: []string{"spec.nativeOIDC.ingress.host"},
+		},
+		{
+			name: "nativeOIDC ingress host is in spec.hosts",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.Hosts = []string{"wiki.example.org"}
+				app.Spec.NativeOIDC.Ingress.Host = "wiki.example.org"
+			},
+			fields: []string{"spec.nativeOIDC.ingress.host"},
+		},
+		{
+			name: "nativeOIDC ingress without class",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.Ingress.ClassName = ""
+			},
+			fields: []string{"spec.nativeOIDC.ingress.className"},
+		},
+		{
+			name: "nativeOIDC ingress without class in HTTPRoute mode",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.Ingress.ClassName = ""
+				app.Spec.Routing = &accessv1alpha1.RoutingConfig{Mode: "HTTPRoute", Gateway: &accessv1alpha1.GatewayRef{Name: "public"}}
+			},
+		},
+		{
+			name: "nativeOIDC ingress without class in Tunnel mode",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.Ingress.ClassName = ""
+				app.Spec.Routing = &accessv1alpha1.RoutingConfig{Mode: "Tunnel", TunnelName: "home"}
+			},
+			fields: []string{"spec.nativeOIDC.ingress.className"},
+		},
+		{
+			name: "nativeOIDC ingress path without slash and unknown pathType",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.NativeOIDC.Ingress.Path = "login"
+				app.Spec.NativeOIDC.Ingress.PathType = "Regex"
+			},
+			fields: []string{"spec.nativeOIDC.ingress.path", "spec.nativeOIDC.ingress.pathType"},
+		},
+		{
+			name: "nativeOIDC ingress path together with routes",
+			mutate: func(app *accessv1alpha1.SecuredApplication) {
+				nativeOIDC(app)
+				app.Spec.Backend = nil
+				app.Spec.Routes = []accessv1alpha1.Route{{Path: "/", Backend: accessv1alpha1.Backend{ServiceName: "web", ServicePort: 80}}}
+				app.Spec.NativeOIDC.Ingress.Path = "/login"
+			},
+			fields: []string{"spec.nativeOIDC.ingress.path"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := validApp()
+			tt.mutate(app)
+
+			var fields []string
+			for _, err := range ValidateSecuredApplication(app) {
+				fields = append(fields, err.Field)
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("errors on %q, want %q", fields, tt.fields)
+			}
+		})
+	}
+}
+
+// claimant returns a SecuredApplication claiming host, created at the given
+// minute.
+func claimant(namespace, name, host string, minute int) *accessv1alpha1.SecuredApplication {
+	app := validApp()
+	app.Namespace = namespace
+	app.Name = name
+	app.Spec.Host = host
+	app.CreationTimestamp = metav1.NewTime(time.Date(2026, 1, 1, 0, minute, 0, 0, time.UTC))
+	return app
+}
+
+func newHostValidator(t *testing.T, existing ...client.Object) *SecuredApplicationValidator {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := accessv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(existing...).
+		WithIndex(&accessv1alpha1.SecuredApplication{}, hosts.IndexField, hosts.Index).
+		Build()
+	return &SecuredApplicationValidator{Client: c}
+}
+
+// invalidFields returns the field paths of an Invalid error's causes.
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsInvalid(err) {
+		t.Fatalf("got %v, want an Invalid error", err)
+	}
+	var fields []string
+	for _, cause := range err.(apierrors.APIStatus).Status().Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	return fields
+}
+
+func TestValidateHosts(t *testing.T) {
+	// wiki claims wiki.example.com first; the newer docs lost that host to it.
+	wiki := claimant("default", "wiki", "wiki.example.com", 0)
+	wiki.Spec.NativeOIDC = &accessv1alpha1.NativeOIDCConfig{
+		Ingress: &accessv1alpha1.OIDCIngressConfig{Host: "wiki-internal.example.com", ClassName: "nginx"},
+	}
+	docs := claimant("team", "docs", "wiki.example.com", 5)
+	docs.Spec.NativeOIDC = &accessv1alpha1.NativeOIDCConfig{
+		Ingress: &accessv1alpha1.OIDCIngressConfig{Host: "docs-internal.example.com", ClassName: "nginx"},
+	}
+
+	tests := []struct {
+		name   string
+		update bool
+		app    func() *accessv1alpha1.SecuredApplication
+		fields []string
+	}{
+		{
+			name: "create with a free host",
+			app:  func() *accessv1alpha1.SecuredApplication { return claimant("default", "blog", "blog.example.com", 0) },
+		},
+		{
+			name:   "create with a claimed host",
+			app:    func() *accessv1alpha1.SecuredApplication { return claimant("default", "blog", "wiki.example.com", 0) },
+			fields: []string{"spec.host"},
+		},
+		{
+			name: "create with a claimed host in another case",
+			app: func() *accessv1alpha1.SecuredApplication {
+				return claimant("default", "blog", "Wiki.Example.com", 0)
+			},
+			fields: []string{"spec.host"},
+		},
+		{
+			name: "create with a claimed additional host",
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := claimant("default", "blog", "blog.example.com", 0)
+				app.Spec.Hosts = []string{"blog.example.org", "wiki.example.com"}
+				return app
+			},
+			fields: []string{"spec.hosts[1]"},
+		},
+		{
+			name: "create with a claimed host in spec.hosts only",
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := claimant("default", "blog", "", 0)
+				app.Spec.Hosts = []string{"blog.example.com", "wiki.example.com"}
+				return app
+			},
+			fields: []string{"spec.hosts[1]"},
+		},
+		{
+			name: "create with a claimed native OIDC host",
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := claimant("default", "blog", "blog.example.com", 0)
+				app.Spec.NativeOIDC = &accessv1alpha1.NativeOIDCConfig{
+					Ingress: &accessv1alpha1.OIDCIngressConfig{Host: "wiki-internal.example.com", ClassName: "nginx"},
+				}
+				return app
+			},
+			fields: []string{"spec.nativeOIDC.ingress.host"},
+		},
+		{
+			name: "create with a claimed host and an invalid spec",
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := claimant("default", "blog", "wiki.example.com", 0)
+				app.Spec.Access.BypassPaths = []string{"healthz"}
+				return app
+			},
+			fields: []string{"spec.access.bypassPaths[0]", "spec.host"},
+		},
+		{
+			name:   "winner keeps its host on update",
+			update: true,
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := wiki.DeepCopy()
+				app.Spec.Access.Roles = []string{"admin", "editor"}
+				return app
+			},
+		},
+		{
+			name:   "loser keeps the contested host on update",
+			update: true,
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := docs.DeepCopy()
+				app.Spec.Access.Roles = []string{"admin", "editor"}
+				return app
+			},
+			fields: []string{"spec.host"},
+		},
+		{
+			name:   "loser moves to a free host",
+			update: true,
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := docs.DeepCopy()
+				app.Spec.Host = "docs.example.com"
+				return app
+			},
+		},
+		{
+			name:   "update onto a host claimed by an older object",
+			update: true,
+			app: func() *accessv1alpha1.SecuredApplication {
+				return claimant("default", "blog", "wiki.example.com", 10)
+			},
+			fields: []string{"spec.host"},
+		},
+		{
+			name:   "update onto a host claimed by a newer object",
+			update: true,
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := wiki.DeepCopy()
+				app.Spec.NativeOIDC.Ingress.Host = "docs-internal.example.com"
+				return app
+			},
+		},
+		{
+			name:   "loser being deleted",
+			update: true,
+			app: func() *accessv1alpha1.SecuredApplication {
+				app := docs.DeepCopy()
+				now := metav1.NewTime(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+				app.DeletionTimestamp = &now
+				app.Finalizers = []string{"access.twiechert.de/finalizer"}
+				return app
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newHostValidator(t, wiki.DeepCopy(), docs.DeepCopy())
+			app := tt.app()
+
+			var err error
+			if tt.update {
+				_, err = v.ValidateUpdate(context.Background(), app, app)
+			} else {
+				app.CreationTimestamp = metav1.Time{}
+				_, err = v.ValidateCreate(context.Background(), app)
+			}
+			if fields := invalidFields(t, err); !slices.Equal(fields, tt.fields) {
+				t.Errorf("errors on %q, want %q", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestValidateHostsWithoutClient(t *testing.T) {
+	v := &SecuredApplicationValidator{}
+	if _, err := v.ValidateCreate(context.Background(), validApp()); err != nil {
+		t.Errorf("ValidateCreate: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package zitadel
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twiechert/cf-zitadel-access-operator/internal/metrics"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/retry"
+)
+
+const (
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	tokenPath = "/oauth/v2/token"
+
+	// The audience scope is required for the token to be accepted by the
+	// Management API.
+	managementAPIScope = "openid urn:zitadel:iam:org:project:id:zitadel:aud"
+
+	// assertionLifetime is how long the signed JWT assertion is valid for.
+	assertionLifetime = time.Hour
+
+	// refreshBefore is how long before expiry a cached token is replaced.
+	refreshBefore = time.Minute
+)
+
+// TokenSource supplies bearer tokens for the Management API.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource for a Personal Access Token.
+type StaticToken string
+
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// ServiceAccountKey is the JSON key file Zitadel issues for a machine user.
+type ServiceAccountKey struct {
+	Type   string `json:"type"`
+	KeyID  string `json:"keyId"`
+	Key    string `json:"key"`
+	UserID string `json:"userId"`
+}
+
+// NewJWTProfileTokenSource returns a TokenSource that exchanges a JWT signed
+// with the machine user's key for short-lived access tokens (JWT profile
+// grant). Tokens are cached and refreshed shortly before they expire.
+func NewJWTProfileTokenSource(baseURL string, keyJSON []byte) (TokenSource, error) {
+	var key ServiceAccountKey
+	if err := json.Unmarshal(keyJSON, &key); err != nil {
+		return nil, fmt.Errorf("unmarshal service account key: %w", err)
+	}
+	if key.KeyID == "" || key.UserID == "" || key.Key == "" {
+		return nil, fmt.Errorf("service account key must contain keyId, userId and key")
+	}
+
+	privateKey, err := parsePrivateKey(key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := strings.TrimSuffix(baseURL, "/")
+	return &jwtProfileSource{
+		issuer:     issuer,
+		tokenURL:   issuer + tokenPath,
+		keyID:      key.KeyID,
+		userID:     key.UserID,
+		privateKey: privateKey,
+		http:       &http.Client{},
+	}, nil
+}
+
+func parsePrivateKey(keyPEM string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("service account key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse service account key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("service account key is not an RSA key")
+	}
+	return key, nil
+}
+
+type jwtProfileSource struct {
+	issuer     string
+	tokenURL   string
+	keyID      string
+	userID     string
+	privateKey *rsa.PrivateKey
+	http       *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (s *jwtProfileSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Add(refreshBefore).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	assertion, err := s.signAssertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {jwtBearerGrantType},
+		"scope":      {managementAPIScope},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	start := time.Now()
+	resp, err := s.http.Do(req)
+	if err != nil {
+		metrics.ObserveAPIRequest("zitadel", http.MethodPost, tokenPath, 0, time.Since(start))
+		return "", fmt.Errorf("execute token request: %w", err)
+	}
+	defer resp.Body.Close()
+	metrics.ObserveAPIRequest("zitadel", http.MethodPost, tokenPath, resp.StatusCode, time.Since(start))
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read token response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		apiErr := &APIError{
+			Method:     http.MethodPost,
+			Path:       tokenPath,
+			StatusCode: resp.StatusCode,
+			RetryAfter: retry.ParseRetryAfter(resp.Header.Get("Retry-After")),
+			Body:       string(respBody),
+		}
+		// The token endpoint answers with an OAuth error, not a gRPC status.
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(respBody, &oauthErr) == nil {
+			apiErr.Message = oauthErr.Error
+			if oauthErr.Description != "" {
+				apiErr.Message += ": " + oauthErr.Description
+			}
+		}
+		return "", apiErr
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("unmarshal token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("zitadel token endpoint returned no access token")
+	}
+
+	s.token = result.AccessToken
+	s.expiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// signAssertion builds the RS256-signed JWT presented to the token endpoint.
+func (s *jwtProfileSource) signAssertion(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": s.keyID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal jwt header: %w", err)
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss": s.userID,
+		"sub": s.userID,
+		"aud": s.issuer,
+		"iat": now.Unix(),
+		"exp": now.Add(assertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal jwt claims: %w", err)
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign jwt assertion: %w", err)
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
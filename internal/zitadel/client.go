@@ -7,9 +7,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/twiechert/cf-zitadel-access-operator/internal/metrics"
+	"github.com/twiechert/cf-zitadel-access-operator/internal/retry"
 )
 
+// searchPageSize is the query.limit used when paging through _search endpoints.
+const searchPageSize = 100
+
 // Project represents a Zitadel project.
 type Project struct {
 	ID   string `json:"id"`
@@ -45,54 +53,115 @@ type AppConfig struct {
 	AccessTokenRoleAssertion bool     `json:"accessTokenRoleAssertion,omitempty"`
 }
 
-// Client talks to the Zitadel Management API.
+// AmbiguousError is returned when a lookup by name matches more than one object.
+type AmbiguousError struct {
+	// Kind is the type of object looked up, e.g. "project" or "app".
+	Kind string
+	Name string
+	IDs  []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%d Zitadel %ss are named %q (IDs %s); rename all but one", len(e.IDs), e.Kind, e.Name, strings.Join(e.IDs, ", "))
+}
+
+// Client talks to the Zitadel Management API. Failed API calls return errors
+// wrapping *APIError; rate limits and server errors are retried with backoff.
 type Client interface {
+	// GetProjectByName returns the project with the given name, nil if there
+	// is none, or an *AmbiguousError if several match.
 	GetProjectByName(ctx context.Context, name string) (*Project, error)
 	ListProjectRoles(ctx context.Context, projectID string) ([]Role, error)
+	// GetAppByName returns the app with the given name in a project, nil if
+	// there is none, or an *AmbiguousError if several match.
 	GetAppByName(ctx context.Context, projectID, name string) (*App, error)
+	// GetAppConfig returns the OIDC configuration of an app, or nil if the
+	// app does not exist.
+	GetAppConfig(ctx context.Context, projectID, appID string) (*AppConfig, error)
 	CreateApp(ctx context.Context, projectID string, config AppConfig) (*App, error)
 	UpdateApp(ctx context.Context, projectID, appID string, config AppConfig) error
+	// RegenerateClientSecret replaces the client secret of an OIDC app and
+	// returns the new secret. The old secret stops working immediately.
+	RegenerateClientSecret(ctx context.Context, projectID, appID string) (string, error)
+	// DeleteApp deletes an app. Deleting an app that no longer exists is not an error.
 	DeleteApp(ctx context.Context, projectID, appID string) error
 }
 
-// NewClient creates a Zitadel Management API client using a Personal Access Token.
-func NewClient(baseURL, token string) Client {
+// NewClient creates a Zitadel Management API client. Use StaticToken for a
+// Personal Access Token or NewJWTProfileTokenSource for a machine-user key.
+func NewClient(baseURL string, tokens TokenSource) Client {
 	return &httpClient{
 		baseURL: baseURL,
-		token:   token,
+		tokens:  tokens,
 		http:    &http.Client{},
 	}
 }
 
 type httpClient struct {
 	baseURL string
-	token   string
+	tokens  TokenSource
 	http    *http.Client
 }
 
 func (c *httpClient) do(ctx context.Context, method, path string, body any) ([]byte, error) {
-	var reqBody io.Reader
+	return c.request(ctx, method, path, body, retry.Idempotent(method))
+}
+
+// doQuery sends a POST that only reads, such as a _search, so server errors
+// are retried as they would be for a GET.
+func (c *httpClient) doQuery(ctx context.Context, path string, body any) ([]byte, error) {
+	return c.request(ctx, http.MethodPost, path, body, true)
+}
+
+// request marshals body and sends it, retrying server errors only if the
+// request is idempotent.
+func (c *httpClient) request(ctx context.Context, method, path string, body any, idempotent bool) ([]byte, error) {
+	var payload []byte
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("marshal request: %w", err)
 		}
-		reqBody = bytes.NewReader(b)
+		payload = b
+	}
+
+	// Fetching a token has no side effects, so it is retried even when the
+	// request itself is not.
+	token, err := retry.Do(ctx, true, func() (string, error) {
+		return c.tokens.Token(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get access token: %w", err)
+	}
+
+	return retry.Do(ctx, idempotent, func() ([]byte, error) {
+		return c.send(ctx, method, path, payload, token)
+	})
+}
+
+// send performs a single request and converts non-2xx responses into *APIError.
+func (c *httpClient) send(ctx context.Context, method, path string, payload []byte, token string) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
+	start := time.Now()
 	resp, err := c.http.Do(req)
 	if err != nil {
+		metrics.ObserveAPIRequest("zitadel", method, path, 0, time.Since(start))
 		return nil, fmt.Errorf("execute request: %w", err)
 	}
 	defer resp.Body.Close()
+	metrics.ObserveAPIRequest("zitadel", method, path, resp.StatusCode, time.Since(start))
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -100,111 +169,179 @@ func (c *httpClient) do(ctx context.Context, method, path string, body any) ([]b
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("zitadel API %s %s returned %d: %s", method, path, resp.StatusCode, string(respBody))
+		apiErr := &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			RetryAfter: retry.ParseRetryAfter(resp.Header.Get("Retry-After")),
+			Body:       string(respBody),
+		}
+		var status struct {
+			Code    int           `json:"code"`
+			Message string        `json:"message"`
+			Details []ErrorDetail `json:"details"`
+		}
+		if json.Unmarshal(respBody, &status) == nil {
+			apiErr.Code = status.Code
+			apiErr.Message = status.Message
+			apiErr.Details = status.Details
+		}
+		return nil, apiErr
 	}
 
 	return respBody, nil
 }
 
-func (c *httpClient) GetProjectByName(ctx context.Context, name string) (*Project, error) {
-	body := map[string]any{
-		"queries": []map[string]any{
-			{
-				"nameQuery": map[string]any{
-					"name":   name,
-					"method": "TEXT_QUERY_METHOD_EQUALS",
-				},
+// search pages through a Zitadel _search endpoint using query.offset/limit
+// until details.totalResult items have been read, and returns the raw items.
+func (c *httpClient) search(ctx context.Context, path string, queries []map[string]any) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	for {
+		body := map[string]any{
+			"query": map[string]any{
+				"offset": strconv.Itoa(len(items)),
+				"limit":  searchPageSize,
+				"asc":    true,
+			},
+		}
+		if len(queries) > 0 {
+			body["queries"] = queries
+		}
+
+		respBody, err := c.doQuery(ctx, path, body)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Details struct {
+				// totalResult is a uint64 and therefore encoded as a JSON string.
+				TotalResult json.Number `json:"totalResult"`
+			} `json:"details"`
+			Result []json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return nil, fmt.Errorf("unmarshal search response: %w", err)
+		}
+		items = append(items, result.Result...)
+
+		total, err := result.Details.TotalResult.Int64()
+		if err != nil || len(result.Result) == 0 || int64(len(items)) >= total {
+			return items, nil
+		}
+	}
+}
+
+// nameQuery builds the exact-match name filter used by project and app searches.
+func nameQuery(name string) []map[string]any {
+	return []map[string]any{
+		{
+			"nameQuery": map[string]any{
+				"name":   name,
+				"method": "TEXT_QUERY_METHOD_EQUALS",
 			},
 		},
 	}
+}
 
-	respBody, err := c.do(ctx, http.MethodPost, "/management/v1/projects/_search", body)
+func (c *httpClient) GetProjectByName(ctx context.Context, name string) (*Project, error) {
+	items, err := c.search(ctx, "/management/v1/projects/_search", nameQuery(name))
 	if err != nil {
 		return nil, fmt.Errorf("search projects: %w", err)
 	}
 
-	var result struct {
-		Result []struct {
-			ID   string `json:"id"`
-			Name string `json:"name"`
-		} `json:"result"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("unmarshal project search: %w", err)
+	projects := make([]Project, len(items))
+	for i, item := range items {
+		if err := json.Unmarshal(item, &projects[i]); err != nil {
+			return nil, fmt.Errorf("unmarshal project search: %w", err)
+		}
 	}
 
-	if len(result.Result) == 0 {
+	switch len(projects) {
+	case 0:
 		return nil, nil
+	case 1:
+		return &projects[0], nil
 	}
-
-	return &Project{
-		ID:   result.Result[0].ID,
-		Name: result.Result[0].Name,
-	}, nil
+	ids := make([]string, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ID
+	}
+	return nil, &AmbiguousError{Kind: "project", Name: name, IDs: ids}
 }
 
 func (c *httpClient) ListProjectRoles(ctx context.Context, projectID string) ([]Role, error) {
 	path := fmt.Sprintf("/management/v1/projects/%s/roles/_search", projectID)
-	respBody, err := c.do(ctx, http.MethodPost, path, map[string]any{})
+	items, err := c.search(ctx, path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("search roles: %w", err)
 	}
 
-	var result struct {
-		Result []struct {
-			Key         string `json:"key"`
-			DisplayName string `json:"displayName"`
-		} `json:"result"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("unmarshal role search: %w", err)
-	}
-
-	roles := make([]Role, len(result.Result))
-	for i, r := range result.Result {
-		roles[i] = Role{Key: r.Key, DisplayName: r.DisplayName}
+	roles := make([]Role, len(items))
+	for i, item := range items {
+		if err := json.Unmarshal(item, &roles[i]); err != nil {
+			return nil, fmt.Errorf("unmarshal role search: %w", err)
+		}
 	}
 	return roles, nil
 }
 
 func (c *httpClient) GetAppByName(ctx context.Context, projectID, name string) (*App, error) {
 	path := fmt.Sprintf("/management/v1/projects/%s/apps/_search", projectID)
-	body := map[string]any{
-		"queries": []map[string]any{
-			{
-				"nameQuery": map[string]any{
-					"name":   name,
-					"method": "TEXT_QUERY_METHOD_EQUALS",
-				},
-			},
-		},
-	}
-
-	respBody, err := c.do(ctx, http.MethodPost, path, body)
+	items, err := c.search(ctx, path, nameQuery(name))
 	if err != nil {
 		return nil, fmt.Errorf("search apps: %w", err)
 	}
 
-	var result struct {
-		Result []struct {
+	apps := make([]App, len(items))
+	for i, item := range items {
+		var result struct {
 			ID         string `json:"id"`
 			OIDCConfig struct {
 				ClientID string `json:"clientId"`
 			} `json:"oidcConfig"`
-		} `json:"result"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("unmarshal app search: %w", err)
+		}
+		if err := json.Unmarshal(item, &result); err != nil {
+			return nil, fmt.Errorf("unmarshal app search: %w", err)
+		}
+		apps[i] = App{ID: result.ID, ClientID: result.OIDCConfig.ClientID}
 	}
 
-	if len(result.Result) == 0 {
+	switch len(apps) {
+	case 0:
 		return nil, nil
+	case 1:
+		return &apps[0], nil
+	}
+	ids := make([]string, len(apps))
+	for i, a := range apps {
+		ids[i] = a.ID
 	}
+	return nil, &AmbiguousError{Kind: "app", Name: name, IDs: ids}
+}
 
-	return &App{
-		ID:       result.Result[0].ID,
-		ClientID: result.Result[0].OIDCConfig.ClientID,
-	}, nil
+func (c *httpClient) GetAppConfig(ctx context.Context, projectID, appID string) (*AppConfig, error) {
+	path := fmt.Sprintf("/management/v1/projects/%s/apps/%s", projectID, appID)
+	respBody, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get app: %w", err)
+	}
+
+	var result struct {
+		App struct {
+			Name       string    `json:"name"`
+			OIDCConfig AppConfig `json:"oidcConfig"`
+		} `json:"app"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal app: %w", err)
+	}
+	config := result.App.OIDCConfig
+	config.Name = result.App.Name
+	return &config, nil
 }
 
 func (c *httpClient) CreateApp(ctx context.Context, projectID string, config AppConfig) (*App, error) {
@@ -236,7 +373,7 @@ func (c *httpClient) UpdateApp(ctx context.Context, projectID, appID string, con
 	if err != nil {
 		// Zitadel returns 400 "No changes" when the config is already identical.
 		// Treat this as a successful no-op.
-		if strings.Contains(err.Error(), "No changes") {
+		if IsNoChanges(err) {
 			return nil
 		}
 		return fmt.Errorf("update app: %w", err)
@@ -244,10 +381,29 @@ func (c *httpClient) UpdateApp(ctx context.Context, projectID, appID string, con
 	return nil
 }
 
+func (c *httpClient) RegenerateClientSecret(ctx context.Context, projectID, appID string) (string, error) {
+	path := fmt.Sprintf("/management/v1/projects/%s/apps/%s/oidc_config/_generate_client_secret", projectID, appID)
+	respBody, err := c.do(ctx, http.MethodPost, path, map[string]any{})
+	if err != nil {
+		return "", fmt.Errorf("regenerate client secret: %w", err)
+	}
+
+	var result struct {
+		ClientSecret string `json:"clientSecret"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("unmarshal client secret response: %w", err)
+	}
+	if result.ClientSecret == "" {
+		return "", fmt.Errorf("regenerate client secret: response contained no secret")
+	}
+	return result.ClientSecret, nil
+}
+
 func (c *httpClient) DeleteApp(ctx context.Context, projectID, appID string) error {
 	path := fmt.Sprintf("/management/v1/projects/%s/apps/%s", projectID, appID)
 	_, err := c.do(ctx, http.MethodDelete, path, nil)
-	if err != nil {
+	if err != nil && !IsNotFound(err) {
 		return fmt.Errorf("delete app: %w", err)
 	}
 	return nil
@@ -0,0 +1,42 @@
+package zitadel
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DiffAppConfig lists the human-readable differences between the live and
+// the desired OIDC configuration of an app, e.g.
+// `redirectUris: ["https://a/callback"] → ["https://b/callback"]`. The name
+// is not compared since UpdateApp does not change it.
+func DiffAppConfig(live, desired AppConfig) []string {
+	var changes []string
+	diffString := func(field, live, desired string) {
+		if live != desired {
+			changes = append(changes, fmt.Sprintf("%s: %q → %q", field, live, desired))
+		}
+	}
+	diffList := func(field string, live, desired []string) {
+		if !slices.Equal(live, desired) {
+			changes = append(changes, fmt.Sprintf("%s: %q → %q", field, live, desired))
+		}
+	}
+	diffBool := func(field string, live, desired bool) {
+		if live != desired {
+			changes = append(changes, fmt.Sprintf("%s: %t → %t", field, live, desired))
+		}
+	}
+
+	diffList("redirectUris", live.RedirectURIs, desired.RedirectURIs)
+	diffList("postLogoutRedirectUris", live.PostLogoutRedirectURIs, desired.PostLogoutRedirectURIs)
+	diffList("responseTypes", live.ResponseTypes, desired.ResponseTypes)
+	diffList("grantTypes", live.GrantTypes, desired.GrantTypes)
+	diffString("appType", live.AppType, desired.AppType)
+	diffString("authMethodType", live.AuthMethodType, desired.AuthMethodType)
+	diffString("accessTokenType", live.AccessTokenType, desired.AccessTokenType)
+	diffBool("devMode", live.DevMode, desired.DevMode)
+	diffBool("idTokenRoleAssertion", live.IDTokenRoleAssertion, desired.IDTokenRoleAssertion)
+	diffBool("idTokenUserinfoAssertion", live.IDTokenUserinfoAssertion, desired.IDTokenUserinfoAssertion)
+	diffBool("accessTokenRoleAssertion", live.AccessTokenRoleAssertion, desired.AccessTokenRoleAssertion)
+	return changes
+}
@@ -0,0 +1,94 @@
+package zitadel
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// noChangesMessage is the message Zitadel uses when an update would not
+	// change anything.
+	noChangesMessage = "No changes"
+)
+
+// ErrorDetail is an entry of the "details" array in a Zitadel error response.
+type ErrorDetail struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// APIError is returned for non-2xx responses from the Zitadel API.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+
+	// Code is the gRPC status code from the response body (e.g. 5 = NotFound).
+	Code int
+
+	// Message is the top-level error message from the response body.
+	Message string
+
+	// Details holds Zitadel's error details, whose IDs identify the error
+	// (e.g. "COMMAND-1m88i").
+	Details []ErrorDetail
+
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+
+	// Body is the raw response body, used when it could not be decoded.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("zitadel API %s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+	}
+	if e.Code == 0 {
+		return fmt.Sprintf("zitadel API %s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("zitadel API %s %s returned %d: %s (code %d)", e.Method, e.Path, e.StatusCode, e.Message, e.Code)
+}
+
+// Retryable reports whether the request may succeed if sent again later.
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// HTTPStatus returns the response status code, for retry.Do.
+func (e *APIError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// RetryDelay returns the delay requested by the Retry-After header, for
+// retry.Do.
+func (e *APIError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsNoChanges reports whether err is Zitadel's rejection of an update that
+// would not change anything.
+func IsNoChanges(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	if strings.HasPrefix(apiErr.Message, noChangesMessage) {
+		return true
+	}
+	for _, d := range apiErr.Details {
+		if d.Message == noChangesMessage {
+			return true
+		}
+	}
+	return false
+}